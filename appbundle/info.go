@@ -0,0 +1,118 @@
+package appbundle
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+)
+
+// Info is the subset of a bundle's Info.plist the step works with.
+type Info struct {
+	BundleID           string
+	Name               string
+	DisplayName        string
+	ShortVersion       string
+	BuildNumber        string
+	Executable         string
+	PackageType        string
+	PlatformName       string
+	MinimumOSVersion   string
+	SupportedPlatforms []string
+
+	Raw serialized.Object
+}
+
+// ReadInfo reads the Info.plist of an iOS, tvOS or watchOS bundle (they use the flat bundle layout).
+func ReadInfo(bundlePth string) (Info, error) {
+	raw, err := readPlist(filepath.Join(bundlePth, "Info.plist"))
+	if err != nil {
+		return Info{}, err
+	}
+
+	info := Info{
+		BundleID:         stringValue(raw, "CFBundleIdentifier"),
+		Name:             stringValue(raw, "CFBundleName"),
+		DisplayName:      stringValue(raw, "CFBundleDisplayName"),
+		ShortVersion:     stringValue(raw, "CFBundleShortVersionString"),
+		BuildNumber:      stringValue(raw, "CFBundleVersion"),
+		Executable:       stringValue(raw, "CFBundleExecutable"),
+		PackageType:      stringValue(raw, "CFBundlePackageType"),
+		PlatformName:     stringValue(raw, "DTPlatformName"),
+		MinimumOSVersion: stringValue(raw, "MinimumOSVersion"),
+		Raw:              raw,
+	}
+	if platforms, err := raw.StringSlice("CFBundleSupportedPlatforms"); err == nil {
+		info.SupportedPlatforms = platforms
+	}
+
+	return info, nil
+}
+
+// IsApplication ...
+func (i Info) IsApplication() bool {
+	return i.PackageType == "APPL"
+}
+
+// IsWatchApp is true for both WatchKit apps and single-target watchOS apps.
+func (i Info) IsWatchApp() bool {
+	if boolValue(i.Raw, "WKWatchKitApp") || boolValue(i.Raw, "WKApplication") {
+		return true
+	}
+	return strings.HasPrefix(strings.ToLower(i.PlatformName), "watch")
+}
+
+// IsAppClip ...
+func (i Info) IsAppClip() bool {
+	_, err := i.Raw.Object("NSAppClip")
+	return err == nil
+}
+
+// IsTestRunner is true for the XCTest runner apps generated for UI test targets.
+func (i Info) IsTestRunner() bool {
+	return strings.HasSuffix(i.BundleID, ".xctrunner")
+}
+
+// ArchiveApplicationPath returns the path of the main application in an xcarchive,
+// read from the ApplicationProperties.ApplicationPath key of the archive's Info.plist.
+func ArchiveApplicationPath(archivePth string) (string, error) {
+	raw, err := readPlist(filepath.Join(archivePth, "Info.plist"))
+	if err != nil {
+		return "", err
+	}
+
+	properties, err := raw.Object("ApplicationProperties")
+	if err != nil {
+		return "", err
+	}
+	applicationPath, err := properties.String("ApplicationPath")
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(archivePth, "Products", applicationPath), nil
+}
+
+// readPlist reads an XML or binary property list whose root is a dictionary.
+func readPlist(pth string) (serialized.Object, error) {
+	data, err := plistutil.NewPlistDataFromFile(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read property list (%s): %s", pth, err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("failed to read property list (%s): empty or not a dictionary", pth)
+	}
+	return serialized.Object(data), nil
+}
+
+func stringValue(object serialized.Object, key string) string {
+	value, _ := object.String(key)
+	return value
+}
+
+func boolValue(object serialized.Object, key string) bool {
+	value, _ := object.Bool(key)
+	return value
+}
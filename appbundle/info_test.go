@@ -0,0 +1,245 @@
+package appbundle
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"howett.net/plist"
+)
+
+func writeInfoPlist(t *testing.T, dir string, info map[string]interface{}, format int) {
+	t.Helper()
+
+	content, err := plist.Marshal(info, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Info.plist"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadInfo(t *testing.T) {
+	app := map[string]interface{}{
+		"CFBundleIdentifier":         "io.bitrise.App",
+		"CFBundleName":               "App",
+		"CFBundleDisplayName":        "My App",
+		"CFBundleShortVersionString": "1.2.3",
+		"CFBundleVersion":            "42",
+		"CFBundleExecutable":         "App",
+		"CFBundlePackageType":        "APPL",
+		"DTPlatformName":             "iphonesimulator",
+		"MinimumOSVersion":           "16.0",
+		"CFBundleSupportedPlatforms": []string{"iPhoneSimulator"},
+		"UIDeviceFamily":             []int{1, 2},
+	}
+	want := Info{
+		BundleID:           "io.bitrise.App",
+		Name:               "App",
+		DisplayName:        "My App",
+		ShortVersion:       "1.2.3",
+		BuildNumber:        "42",
+		Executable:         "App",
+		PackageType:        "APPL",
+		PlatformName:       "iphonesimulator",
+		MinimumOSVersion:   "16.0",
+		SupportedPlatforms: []string{"iPhoneSimulator"},
+	}
+
+	tests := []struct {
+		name   string
+		format int
+	}{
+		{name: "xml", format: plist.XMLFormat},
+		{name: "binary", format: plist.BinaryFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "App.app")
+			writeInfoPlist(t, dir, app, tt.format)
+
+			got, err := ReadInfo(dir)
+			if err != nil {
+				t.Fatalf("ReadInfo() error = %v", err)
+			}
+			got.Raw = nil
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ReadInfo() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestReadInfoInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{name: "empty", content: nil},
+		{name: "not a plist", content: []byte("CFBundleIdentifier = io.bitrise.App")},
+		{name: "truncated binary", content: []byte("bplist00\xd1\x01\x02")},
+		{name: "binary with out of range offsets", content: append([]byte("bplist00"), append(make([]byte, 6), 0x08, 0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)...)},
+		{name: "array root", content: []byte(`<?xml version="1.0" encoding="UTF-8"?><plist version="1.0"><array><string>a</string></array></plist>`)},
+		{name: "unterminated xml", content: []byte(`<?xml version="1.0" encoding="UTF-8"?><plist version="1.0"><dict><key>CFBundleIdentifier</key>`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "Info.plist"), tt.content, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := ReadInfo(dir); err == nil {
+				t.Errorf("ReadInfo() error = nil, want an error")
+			}
+		})
+	}
+
+	if _, err := ReadInfo(t.TempDir()); err == nil {
+		t.Errorf("ReadInfo() error = nil for a missing Info.plist, want an error")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name       string
+		info       map[string]interface{}
+		extensions bool
+		want       Kind
+	}{
+		{
+			name: "app",
+			info: map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App", "CFBundlePackageType": "APPL"},
+			want: KindApp,
+		},
+		{
+			name:       "extension host",
+			info:       map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App", "CFBundlePackageType": "APPL"},
+			extensions: true,
+			want:       KindExtensionHost,
+		},
+		{
+			name: "watchkit app",
+			info: map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App.watchkitapp", "CFBundlePackageType": "APPL", "WKWatchKitApp": true},
+			want: KindWatchApp,
+		},
+		{
+			name: "single target watch app",
+			info: map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App.watch", "CFBundlePackageType": "APPL", "WKApplication": true},
+			want: KindWatchApp,
+		},
+		{
+			name: "watch app by platform",
+			info: map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App.watch", "CFBundlePackageType": "APPL", "DTPlatformName": "watchsimulator"},
+			want: KindWatchApp,
+		},
+		{
+			name: "app clip",
+			info: map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App.Clip", "CFBundlePackageType": "APPL", "NSAppClip": map[string]interface{}{}},
+			want: KindAppClip,
+		},
+		{
+			name: "test runner",
+			info: map[string]interface{}{"CFBundleIdentifier": "io.bitrise.AppUITests.xctrunner", "CFBundlePackageType": "APPL"},
+			want: KindTestRunner,
+		},
+		{
+			name: "framework",
+			info: map[string]interface{}{"CFBundleIdentifier": "io.bitrise.Core", "CFBundlePackageType": "FMWK"},
+			want: KindUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "App.app")
+			writeInfoPlist(t, dir, tt.info, plist.XMLFormat)
+			if tt.extensions {
+				if err := os.MkdirAll(filepath.Join(dir, "PlugIns", "Widget.appex"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			info, err := ReadInfo(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Classify(dir, info); got != tt.want {
+				t.Errorf("Classify() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveApplicationPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "application archive",
+			info: map[string]interface{}{"ApplicationProperties": map[string]interface{}{"ApplicationPath": "Applications/App.app"}},
+			want: filepath.Join("Products", "Applications", "App.app"),
+		},
+		{
+			name:    "no application properties",
+			info:    map[string]interface{}{"Name": "Core"},
+			wantErr: true,
+		},
+		{
+			name:    "no application path",
+			info:    map[string]interface{}{"ApplicationProperties": map[string]interface{}{}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "App.xcarchive")
+			writeInfoPlist(t, archive, tt.info, plist.BinaryFormat)
+
+			got, err := ArchiveApplicationPath(archive)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ArchiveApplicationPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if want := filepath.Join(archive, tt.want); got != want {
+				t.Errorf("ArchiveApplicationPath() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func FuzzReadInfo(f *testing.F) {
+	for _, format := range []int{plist.XMLFormat, plist.BinaryFormat} {
+		content, err := plist.Marshal(map[string]interface{}{
+			"CFBundleIdentifier":         "io.bitrise.App",
+			"CFBundleSupportedPlatforms": []string{"iPhoneSimulator"},
+			"NSAppClip":                  map[string]interface{}{"NSAppClipRequestEphemeralUserNotification": false},
+		}, format)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(content)
+	}
+
+	f.Fuzz(func(t *testing.T, content []byte) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "Info.plist"), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := ReadInfo(dir)
+		if err != nil {
+			return
+		}
+		_ = Classify(dir, info)
+	})
+}
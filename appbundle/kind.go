@@ -0,0 +1,38 @@
+package appbundle
+
+import (
+	"path/filepath"
+)
+
+// Kind classifies an exported bundle.
+type Kind string
+
+// Bundle kinds.
+const (
+	KindApp           Kind = "app"
+	KindExtensionHost Kind = "extension_host"
+	KindWatchApp      Kind = "watch_app"
+	KindAppClip       Kind = "app_clip"
+	KindTestRunner    Kind = "test_runner"
+	KindUnknown       Kind = "unknown"
+)
+
+// Classify returns the kind of the bundle based on its Info.plist and content.
+// An app that embeds app extensions (PlugIns/*.appex) is an extension host.
+func Classify(bundlePth string, info Info) Kind {
+	switch {
+	case info.IsTestRunner():
+		return KindTestRunner
+	case info.IsAppClip():
+		return KindAppClip
+	case info.IsWatchApp():
+		return KindWatchApp
+	case !info.IsApplication():
+		return KindUnknown
+	}
+
+	if extensions, err := filepath.Glob(filepath.Join(bundlePth, "PlugIns", "*.appex")); err == nil && len(extensions) > 0 {
+		return KindExtensionHost
+	}
+	return KindApp
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	v2log "github.com/bitrise-io/go-utils/v2/log"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appetize"
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/util"
+)
+
+const appetizePackageSuffix = "-appetize.zip"
+
+// appetizeArtifact returns the exported main app of the scheme (see appRole), the app uploaded to Appetize.
+func appetizeArtifact(artifacts []Artifact) (Artifact, error) {
+	idx := slices.IndexFunc(artifacts, func(artifact Artifact) bool { return artifact.Role == appRoleMain })
+	if idx < 0 {
+		return Artifact{}, fmt.Errorf("the scheme's main app is not exported, check the bundle filter inputs and `embedded_app_mode`")
+	}
+	return artifacts[idx], nil
+}
+
+// uploadToAppetize zips the main app the way Appetize expects it (the .app dir at the root of the zip)
+// and uploads it, as a new app or as a new version of the app with the given public key.
+// The package path is returned even if the upload fails.
+func uploadToAppetize(mainArtifact Artifact, outputDir, baseURL, token, publicKey string) (appetize.App, string, error) {
+	name := strings.TrimSuffix(filepath.Base(mainArtifact.Path), filepath.Ext(mainArtifact.Path))
+	packagePth := filepath.Join(outputDir, name+appetizePackageSuffix)
+	if err := util.ZipDir(mainArtifact.Path, packagePth); err != nil {
+		return appetize.App{}, "", fmt.Errorf("failed to package %s: %s", mainArtifact.Path, err)
+	}
+	log.Donef("Package: $BITRISE_DEPLOY_DIR/%s", filepath.Base(packagePth))
+
+	if publicKey != "" {
+		log.Printf("Updating Appetize app %s", publicKey)
+	} else {
+		log.Printf("Uploading new Appetize app")
+	}
+
+	client := appetize.NewClient(baseURL, token, v2log.NewLogger())
+	app, err := client.Upload(packagePth, publicKey)
+	if err != nil {
+		return appetize.App{}, packagePth, err
+	}
+	log.Donef("Uploaded: %s", app.AppURL)
+
+	return app, packagePth, nil
+}
@@ -0,0 +1,174 @@
+package appetize
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v2log "github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-utils/v2/retryhttp"
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// DefaultBaseURL is the address of the Appetize API.
+const DefaultBaseURL = "https://api.appetize.io"
+
+// App is an app uploaded to Appetize.
+type App struct {
+	PublicKey string `json:"publicKey"`
+	AppURL    string `json:"appURL"`
+	Platform  string `json:"platform"`
+}
+
+// Client uploads simulator builds to Appetize.
+type Client struct {
+	baseURL string
+	token   string
+	// Creating an app is not idempotent: it's only retried if the request didn't reach the server.
+	createClient *retryablehttp.Client
+	updateClient *retryablehttp.Client
+}
+
+// NewClient creates a client for the API at baseURL (DefaultBaseURL if empty), authenticated by the API token.
+func NewClient(baseURL, token string, logger v2log.Logger) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	createClient := retryhttp.NewClient(logger)
+	createClient.CheckRetry = retryConnectionErrors
+
+	return &Client{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		token:        token,
+		createClient: createClient,
+		updateClient: retryhttp.NewClient(logger),
+	}
+}
+
+// Upload uploads the zipped .app as a new app, or as a new version of the app
+// with the given public key, if set.
+func (c *Client) Upload(zipPth, publicKey string) (App, error) {
+	url := c.baseURL + "/v1/apps"
+	httpClient := c.createClient
+	if publicKey != "" {
+		url += "/" + publicKey
+		httpClient = c.updateClient
+	}
+
+	// The form is written to a temporary file once, and every attempt reads it from the start.
+	formPth, contentType, err := writeUploadFormFile(zipPth)
+	if err != nil {
+		return App{}, fmt.Errorf("failed to create the upload form: %s", err)
+	}
+	defer func() {
+		_ = os.Remove(formPth)
+	}()
+	formInfo, err := os.Stat(formPth)
+	if err != nil {
+		return App{}, err
+	}
+
+	body := func() (io.Reader, error) {
+		return os.Open(formPth)
+	}
+	req, err := retryablehttp.NewRequest(http.MethodPost, url, retryablehttp.ReaderFunc(body))
+	if err != nil {
+		return App{}, err
+	}
+	req.ContentLength = formInfo.Size()
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-API-KEY", c.token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return App{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return App{}, fmt.Errorf("failed to read response: %s", err)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return App{}, fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(content)))
+	}
+
+	var app App
+	if err := json.Unmarshal(content, &app); err != nil {
+		return App{}, fmt.Errorf("failed to parse response (%s): %s", content, err)
+	}
+	if app.PublicKey == "" {
+		return App{}, fmt.Errorf("no public key in the response: %s", content)
+	}
+
+	return app, nil
+}
+
+// retryConnectionErrors retries the attempts that failed to connect to the server.
+// Responses (including 5xx errors) and timeouts are not retried, as the server may have processed the request.
+func retryConnectionErrors(ctx context.Context, _ *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	var opErr *net.OpError
+	if err != nil && errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true, nil
+	}
+	return false, nil
+}
+
+// writeUploadFormFile writes the multipart upload form into a temporary file,
+// and returns its path and content type.
+func writeUploadFormFile(zipPth string) (string, string, error) {
+	file, err := os.CreateTemp("", "appetize-upload-*")
+	if err != nil {
+		return "", "", err
+	}
+
+	form := multipart.NewWriter(file)
+	writeErr := writeUploadForm(form, zipPth)
+	closeErr := file.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		_ = os.Remove(file.Name())
+		return "", "", err
+	}
+
+	return file.Name(), form.FormDataContentType(), nil
+}
+
+func writeUploadForm(writer *multipart.Writer, zipPth string) error {
+	if err := writer.WriteField("platform", "ios"); err != nil {
+		return err
+	}
+
+	part, err := writer.CreateFormFile("file", filepath.Base(zipPth))
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(zipPth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	if _, err := io.Copy(part, file); err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
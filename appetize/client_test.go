@@ -0,0 +1,211 @@
+package appetize
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	v2log "github.com/bitrise-io/go-utils/v2/log"
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+type receivedUpload struct {
+	method   string
+	path     string
+	apiKey   string
+	platform string
+	fileName string
+	file     string
+}
+
+// flakyTransport fails the first attempts with the given error, then delegates to the default transport.
+type flakyTransport struct {
+	failures int
+	err      error
+	attempts int
+}
+
+func (t *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.attempts++
+	if t.attempts <= t.failures {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, t.err
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientUpload(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	readErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("i/o timeout")}
+
+	tests := []struct {
+		name         string
+		publicKey    string
+		statuses     []int
+		transportErr error
+		failures     int
+		wantPath     string
+		wantRequests int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			name:         "create",
+			statuses:     []int{http.StatusOK},
+			wantPath:     "/v1/apps",
+			wantRequests: 1,
+		},
+		{
+			name:         "update",
+			publicKey:    "KEY",
+			statuses:     []int{http.StatusOK},
+			wantPath:     "/v1/apps/KEY",
+			wantRequests: 1,
+		},
+		{
+			name:         "update is retried on server errors",
+			publicKey:    "KEY",
+			statuses:     []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+			wantPath:     "/v1/apps/KEY",
+			wantRequests: 3,
+		},
+		{
+			name:         "create is not retried on server errors",
+			statuses:     []int{http.StatusInternalServerError, http.StatusOK},
+			wantPath:     "/v1/apps",
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "create is retried on connection errors",
+			statuses:     []int{http.StatusOK},
+			transportErr: dialErr,
+			failures:     2,
+			wantPath:     "/v1/apps",
+			wantRequests: 1,
+			wantAttempts: 3,
+		},
+		{
+			name:         "create is not retried on timeouts",
+			statuses:     []int{http.StatusOK},
+			transportErr: readErr,
+			failures:     1,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "update fails on client errors",
+			publicKey:    "KEY",
+			statuses:     []int{http.StatusUnauthorized},
+			wantPath:     "/v1/apps/KEY",
+			wantRequests: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("TMPDIR", tmpDir)
+
+			zipPth := filepath.Join(t.TempDir(), "App-appetize.zip")
+			if err := os.WriteFile(zipPth, []byte("zip content"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var mu sync.Mutex
+			var received []receivedUpload
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upload := receivedUpload{method: r.Method, path: r.URL.Path, apiKey: r.Header.Get("X-API-KEY")}
+				if err := r.ParseMultipartForm(1 << 20); err == nil {
+					upload.platform = r.FormValue("platform")
+					if file, header, err := r.FormFile("file"); err == nil {
+						content, _ := io.ReadAll(file)
+						upload.fileName = header.Filename
+						upload.file = string(content)
+					}
+				}
+
+				mu.Lock()
+				status := tt.statuses[min(len(received), len(tt.statuses)-1)]
+				received = append(received, upload)
+				mu.Unlock()
+
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					_, _ = io.WriteString(w, `{"publicKey":"KEY","appURL":"https://appetize.io/app/KEY","platform":"ios"}`)
+				}
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL+"/", "TOKEN", v2log.NewLogger())
+			transport := &flakyTransport{failures: tt.failures, err: tt.transportErr}
+			for _, httpClient := range []*retryablehttp.Client{client.createClient, client.updateClient} {
+				httpClient.HTTPClient.Transport = transport
+				httpClient.RetryWaitMin, httpClient.RetryWaitMax = time.Millisecond, time.Millisecond
+			}
+
+			app, err := client.Upload(zipPth, tt.publicKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Upload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (app.PublicKey != "KEY" || app.AppURL != "https://appetize.io/app/KEY") {
+				t.Errorf("Upload() = %+v, want the app from the response", app)
+			}
+
+			if len(received) != tt.wantRequests {
+				t.Fatalf("server received %d requests, want %d", len(received), tt.wantRequests)
+			}
+			for i, upload := range received {
+				want := receivedUpload{method: http.MethodPost, path: tt.wantPath, apiKey: "TOKEN", platform: "ios", fileName: "App-appetize.zip", file: "zip content"}
+				if upload != want {
+					t.Errorf("request %d = %+v, want %+v", i, upload, want)
+				}
+			}
+			if tt.wantAttempts != 0 && transport.attempts != tt.wantAttempts {
+				t.Errorf("client made %d attempts, want %d", transport.attempts, tt.wantAttempts)
+			}
+
+			entries, err := os.ReadDir(tmpDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("temporary files are left behind: %v", entries)
+			}
+		})
+	}
+}
+
+func TestClientUploadMissingPublicKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"appURL":"https://appetize.io/app/KEY"}`)
+	}))
+	defer server.Close()
+
+	zipPth := filepath.Join(t.TempDir(), "App-appetize.zip")
+	if err := os.WriteFile(zipPth, []byte("zip content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewClient(server.URL, "TOKEN", v2log.NewLogger()).Upload(zipPth, ""); err == nil {
+		t.Errorf("Upload() error = nil, want an error for a response without a public key")
+	}
+}
+
+func TestNewClientDefaultBaseURL(t *testing.T) {
+	if got := NewClient("", "TOKEN", v2log.NewLogger()).baseURL; got != DefaultBaseURL {
+		t.Errorf("baseURL = %s, want %s", got, DefaultBaseURL)
+	}
+	if got := NewClient("http://localhost:8080/", "TOKEN", v2log.NewLogger()).baseURL; got != "http://localhost:8080" {
+		t.Errorf("baseURL = %s, want http://localhost:8080", got)
+	}
+}
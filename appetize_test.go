@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestAppetizeArtifact(t *testing.T) {
+	tests := []struct {
+		name      string
+		artifacts []Artifact
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "main app",
+			artifacts: []Artifact{
+				{Path: "/deploy/Widget.app", Role: appRoleEmbedded},
+				{Path: "/deploy/App.app", Role: appRoleMain},
+			},
+			want: "/deploy/App.app",
+		},
+		{
+			name:      "main app not exported",
+			artifacts: []Artifact{{Path: "/deploy/Watch.app", Role: appRoleEmbedded}},
+			wantErr:   true,
+		},
+		{
+			name:    "no apps",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := appetizeArtifact(tt.artifacts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("appetizeArtifact() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Path != tt.want {
+				t.Errorf("appetizeArtifact() = %s, want %s", got.Path, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package appsize
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// totalKey refers to the whole bundle in a budget's limits.
+const totalKey = "total"
+
+// Rule limits the size of a bundle.
+// Limits are absolute sizes in bytes by component (or `total`),
+// MaxGrowthPercent is the allowed growth of every component compared to the baseline report.
+type Rule struct {
+	Limits           map[string]int64 `json:"limits,omitempty"`
+	MaxGrowthPercent *float64         `json:"max_growth_percent,omitempty"`
+}
+
+// Budget is the content of a size budget file:
+//
+//	{
+//	  "limits": {"total": 52428800, "frameworks": 20971520},
+//	  "max_growth_percent": 5,
+//	  "bundles": {
+//	    "io.bitrise.MyApp": {"limits": {"executable": 10485760}}
+//	  }
+//	}
+//
+// The top-level rule applies to every bundle, the rules in bundles override it for the bundle with the given identifier.
+// Rules are keyed by bundle identifier (like the baseline is matched), so they don't depend on the exported names.
+type Budget struct {
+	Rule
+	Bundles map[string]Rule `json:"bundles,omitempty"`
+}
+
+// Violation is a component exceeding its budget.
+type Violation struct {
+	Bundle    string
+	Component string
+	Size      int64
+	Message   string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s %s: %s", v.Bundle, v.Component, v.Message)
+}
+
+// ReadBudget reads and validates a budget file.
+func ReadBudget(pth string) (Budget, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return Budget{}, err
+	}
+
+	var budget Budget
+	if err := json.Unmarshal(content, &budget); err != nil {
+		return Budget{}, fmt.Errorf("failed to parse budget file (%s): %s", pth, err)
+	}
+
+	if err := budget.Rule.validate(); err != nil {
+		return Budget{}, err
+	}
+	for bundleID, rule := range budget.Bundles {
+		if strings.TrimSpace(bundleID) == "" {
+			return Budget{}, fmt.Errorf("bundle rules should be keyed by bundle identifier, for example io.bitrise.MyApp")
+		}
+		if err := rule.validate(); err != nil {
+			return Budget{}, fmt.Errorf("bundle (%s): %s", bundleID, err)
+		}
+	}
+
+	return budget, nil
+}
+
+func (r Rule) validate() error {
+	for key, limit := range r.Limits {
+		if !isBudgetKey(key) {
+			return fmt.Errorf("unknown limit (%s)", key)
+		}
+		if limit < 0 {
+			return fmt.Errorf("negative limit (%s): %d", key, limit)
+		}
+	}
+	if r.MaxGrowthPercent != nil && *r.MaxGrowthPercent < 0 {
+		return fmt.Errorf("negative max_growth_percent: %g", *r.MaxGrowthPercent)
+	}
+	return nil
+}
+
+func isBudgetKey(key string) bool {
+	if key == totalKey {
+		return true
+	}
+	for _, component := range Components {
+		if key == string(component) {
+			return true
+		}
+	}
+	return false
+}
+
+// rule returns the rule of the bundle with the given identifier, falling back to the top-level rule's values.
+func (b Budget) rule(bundleID string) Rule {
+	rule := Rule{Limits: map[string]int64{}, MaxGrowthPercent: b.MaxGrowthPercent}
+	for key, limit := range b.Limits {
+		rule.Limits[key] = limit
+	}
+
+	override, ok := b.Bundles[bundleID]
+	if bundleID == "" || !ok {
+		return rule
+	}
+	for key, limit := range override.Limits {
+		rule.Limits[key] = limit
+	}
+	if override.MaxGrowthPercent != nil {
+		rule.MaxGrowthPercent = override.MaxGrowthPercent
+	}
+	return rule
+}
+
+// Check returns the components of the report exceeding the budget.
+// Growth is only checked if a baseline is given, for bundles present in the baseline (see Report.Match).
+func (b Budget) Check(report Report, baseline *Report) []Violation {
+	var violations []Violation
+	for _, bundle := range report.Bundles {
+		rule := b.rule(bundle.BundleID)
+
+		var baselineBundle *BundleSize
+		if baseline != nil {
+			if previous, ok := baseline.Match(bundle); ok {
+				baselineBundle = &previous
+			}
+		}
+
+		keys := []string{}
+		for _, component := range Components {
+			keys = append(keys, string(component))
+		}
+		keys = append(keys, totalKey)
+
+		for _, key := range keys {
+			size := bundle.size(key)
+
+			if limit, ok := rule.Limits[key]; ok && size > limit {
+				violations = append(violations, Violation{
+					Bundle:    bundle.Name,
+					Component: key,
+					Size:      size,
+					Message:   fmt.Sprintf("%s exceeds the limit of %s", FormatSize(size), FormatSize(limit)),
+				})
+			}
+
+			if rule.MaxGrowthPercent == nil || baselineBundle == nil {
+				continue
+			}
+			previous := baselineBundle.size(key)
+			if previous == 0 && size > 0 {
+				violations = append(violations, Violation{
+					Bundle:    bundle.Name,
+					Component: key,
+					Size:      size,
+					Message: fmt.Sprintf("is new compared to the baseline (0 B -> %s), more than the allowed %g%% growth",
+						FormatSize(size), *rule.MaxGrowthPercent),
+				})
+				continue
+			}
+			if size <= previous {
+				continue
+			}
+			growth := float64(size-previous) / float64(previous) * 100
+			if growth > *rule.MaxGrowthPercent {
+				violations = append(violations, Violation{
+					Bundle:    bundle.Name,
+					Component: key,
+					Size:      size,
+					Message: fmt.Sprintf("grew by %.1f%% (%s -> %s), more than the allowed %g%%",
+						growth, FormatSize(previous), FormatSize(size), *rule.MaxGrowthPercent),
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// Unmatched returns the bundle identifiers of the budget's bundle rules which match none of the report's bundles.
+func (b Budget) Unmatched(report Report) []string {
+	var unmatched []string
+	for bundleID := range b.Bundles {
+		if !slices.ContainsFunc(report.Bundles, func(bundle BundleSize) bool { return bundle.BundleID == bundleID }) {
+			unmatched = append(unmatched, bundleID)
+		}
+	}
+	sort.Strings(unmatched)
+	return unmatched
+}
+
+func (s BundleSize) size(key string) int64 {
+	if key == totalKey {
+		return s.Total
+	}
+	return s.Components[Component(key)]
+}
@@ -0,0 +1,144 @@
+package appsize
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadBudget(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "valid", content: `{"limits": {"total": 100, "frameworks": 50}, "max_growth_percent": 5, "bundles": {"io.bitrise.App": {"limits": {"executable": 10}}}}`},
+		{name: "invalid json", content: `{"limits": `, wantErr: true},
+		{name: "unknown limit", content: `{"limits": {"binary": 100}}`, wantErr: true},
+		{name: "negative limit", content: `{"limits": {"total": -1}}`, wantErr: true},
+		{name: "negative growth", content: `{"max_growth_percent": -5}`, wantErr: true},
+		{name: "empty bundle id", content: `{"bundles": {"": {"limits": {"total": 100}}}}`, wantErr: true},
+		{name: "invalid bundle rule", content: `{"bundles": {"io.bitrise.App": {"limits": {"binary": 100}}}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := filepath.Join(t.TempDir(), "budget.json")
+			if err := os.WriteFile(pth, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadBudget(pth); (err != nil) != tt.wantErr {
+				t.Errorf("ReadBudget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBudgetCheck(t *testing.T) {
+	growth := func(percent float64) *float64 {
+		return &percent
+	}
+	bundle := func(name, bundleID string, executable, frameworks int64) BundleSize {
+		return BundleSize{
+			Name:       name,
+			BundleID:   bundleID,
+			Total:      executable + frameworks,
+			Components: map[Component]int64{ComponentExecutable: executable, ComponentFrameworks: frameworks},
+		}
+	}
+
+	report := Report{Bundles: []BundleSize{
+		bundle("App-1.2.3.app", "io.bitrise.App", 120, 200),
+		bundle("Watch.app", "io.bitrise.App.watchkitapp", 50, 0),
+	}}
+	baseline := &Report{Bundles: []BundleSize{
+		bundle("App.app", "io.bitrise.App", 100, 200),
+		bundle("Watch.app", "io.bitrise.App.watchkitapp", 50, 0),
+	}}
+
+	type violation struct {
+		bundle, component string
+	}
+	tests := []struct {
+		name     string
+		budget   Budget
+		baseline *Report
+		want     []violation
+	}{
+		{
+			name:   "within the limits",
+			budget: Budget{Rule: Rule{Limits: map[string]int64{totalKey: 1000}}},
+		},
+		{
+			name:   "total limit",
+			budget: Budget{Rule: Rule{Limits: map[string]int64{totalKey: 300}}},
+			want:   []violation{{bundle: "App-1.2.3.app", component: totalKey}},
+		},
+		{
+			name: "bundle rule overrides the top-level one",
+			budget: Budget{
+				Rule:    Rule{Limits: map[string]int64{string(ComponentExecutable): 10}},
+				Bundles: map[string]Rule{"io.bitrise.App": {Limits: map[string]int64{string(ComponentExecutable): 500}}},
+			},
+			want: []violation{{bundle: "Watch.app", component: string(ComponentExecutable)}},
+		},
+		{
+			name:     "growth is compared to the baseline matched by bundle id",
+			budget:   Budget{Rule: Rule{MaxGrowthPercent: growth(10)}},
+			baseline: baseline,
+			want:     []violation{{bundle: "App-1.2.3.app", component: string(ComponentExecutable)}},
+		},
+		{
+			name:     "growth within the limit",
+			budget:   Budget{Rule: Rule{MaxGrowthPercent: growth(25)}},
+			baseline: baseline,
+		},
+		{
+			name: "bundle rule is not matched by the bundle name",
+			budget: Budget{
+				Rule:    Rule{Limits: map[string]int64{string(ComponentExecutable): 500}},
+				Bundles: map[string]Rule{"App-1.2.3.app": {Limits: map[string]int64{string(ComponentExecutable): 10}}},
+			},
+		},
+		{
+			name:   "component missing from the baseline is reported as new",
+			budget: Budget{Rule: Rule{MaxGrowthPercent: growth(1000)}},
+			baseline: &Report{Bundles: []BundleSize{
+				bundle("App.app", "io.bitrise.App", 0, 200),
+				bundle("Watch.app", "io.bitrise.App.watchkitapp", 50, 0),
+			}},
+			want: []violation{{bundle: "App-1.2.3.app", component: string(ComponentExecutable)}},
+		},
+		{
+			name:   "growth is not checked without a baseline",
+			budget: Budget{Rule: Rule{MaxGrowthPercent: growth(0)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []violation
+			for _, v := range tt.budget.Check(report, tt.baseline) {
+				got = append(got, violation{bundle: v.Bundle, component: v.Component})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBudgetUnmatched(t *testing.T) {
+	report := Report{Bundles: []BundleSize{
+		{Name: "App-Debug.app", BundleID: "io.bitrise.App"},
+		{Name: "Watch.app"},
+	}}
+	budget := Budget{Bundles: map[string]Rule{
+		"io.bitrise.App":             {},
+		"io.bitrise.App.watchkitapp": {},
+		"App-Debug.app":              {},
+	}}
+
+	if got, want := budget.Unmatched(report), []string{"App-Debug.app", "io.bitrise.App.watchkitapp"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmatched() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,212 @@
+package appsize
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// Component is a part of an app bundle the size report breaks the bundle down to.
+type Component string
+
+// Components
+const (
+	ComponentExecutable     Component = "executable"
+	ComponentFrameworks     Component = "frameworks"
+	ComponentPlugIns        Component = "plugins"
+	ComponentAssetCatalogs  Component = "asset_catalogs"
+	ComponentOtherResources Component = "other_resources"
+)
+
+// Components lists the components in the order they are reported.
+var Components = []Component{
+	ComponentExecutable,
+	ComponentFrameworks,
+	ComponentPlugIns,
+	ComponentAssetCatalogs,
+	ComponentOtherResources,
+}
+
+// BundleSize is the on-disk size of an app bundle, in bytes, broken down by component.
+type BundleSize struct {
+	Name       string              `json:"name"`
+	BundleID   string              `json:"bundle_id,omitempty"`
+	Path       string              `json:"path"`
+	Total      int64               `json:"total"`
+	Components map[Component]int64 `json:"components"`
+}
+
+// Report is the size breakdown of every exported app bundle.
+type Report struct {
+	Bundles []BundleSize `json:"bundles"`
+}
+
+// Measure sums up the size of the regular files in the bundle by component.
+// executable is the bundle's CFBundleExecutable, if known.
+func Measure(bundlePth, executable string) (BundleSize, error) {
+	size := BundleSize{
+		Name:       filepath.Base(bundlePth),
+		Path:       bundlePth,
+		Components: map[Component]int64{},
+	}
+	for _, component := range Components {
+		size.Components[component] = 0
+	}
+
+	if err := filepath.WalkDir(bundlePth, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(bundlePth, pth)
+		if err != nil {
+			return err
+		}
+
+		component := classify(filepath.ToSlash(rel), executable)
+		size.Components[component] += info.Size()
+		size.Total += info.Size()
+
+		return nil
+	}); err != nil {
+		return BundleSize{}, fmt.Errorf("failed to measure %s: %s", bundlePth, err)
+	}
+
+	return size, nil
+}
+
+func classify(rel, executable string) Component {
+	switch {
+	case executable != "" && rel == executable:
+		return ComponentExecutable
+	case strings.HasPrefix(rel, "Frameworks/"):
+		return ComponentFrameworks
+	case strings.HasPrefix(rel, "PlugIns/") || strings.HasPrefix(rel, "Extensions/"):
+		return ComponentPlugIns
+	case filepath.Ext(rel) == ".car":
+		return ComponentAssetCatalogs
+	}
+	return ComponentOtherResources
+}
+
+// Match returns the bundle of the report corresponding to the given one, for example in a baseline report.
+// Bundles are matched by their bundle identifier, so renamed artifacts are still compared,
+// and by their name only if either of them has no identifier (like reports written before identifiers were recorded).
+func (r Report) Match(bundle BundleSize) (BundleSize, bool) {
+	if i := r.matchIndex(bundle); i >= 0 {
+		return r.Bundles[i], true
+	}
+	return BundleSize{}, false
+}
+
+// Unmatched returns the bundles of the baseline report no bundle of this report corresponds to.
+func (r Report) Unmatched(baseline Report) []BundleSize {
+	matched := map[int]bool{}
+	for _, bundle := range r.Bundles {
+		matched[baseline.matchIndex(bundle)] = true
+	}
+
+	var unmatched []BundleSize
+	for i, previous := range baseline.Bundles {
+		if !matched[i] {
+			unmatched = append(unmatched, previous)
+		}
+	}
+	return unmatched
+}
+
+func (r Report) matchIndex(bundle BundleSize) int {
+	for i, candidate := range r.Bundles {
+		if bundle.BundleID != "" && candidate.BundleID == bundle.BundleID {
+			return i
+		}
+	}
+	for i, candidate := range r.Bundles {
+		if (bundle.BundleID == "" || candidate.BundleID == "") && candidate.Name == bundle.Name {
+			return i
+		}
+	}
+	return -1
+}
+
+// WriteJSON writes the report to the given path.
+func (r Report) WriteJSON(pth string) error {
+	if r.Bundles == nil {
+		r.Bundles = []BundleSize{}
+	}
+
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(pth, content, 0644)
+}
+
+// ReadReport reads a report written by WriteJSON, for example a baseline from a previous build.
+func ReadReport(pth string) (Report, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var report Report
+	if err := json.Unmarshal(content, &report); err != nil {
+		return Report{}, fmt.Errorf("failed to parse size report (%s): %s", pth, err)
+	}
+	return report, nil
+}
+
+// WriteTable writes the report as a human-readable table.
+func (r Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	header := []string{"Bundle"}
+	for _, component := range Components {
+		header = append(header, string(component))
+	}
+	header = append(header, "total")
+	if _, err := fmt.Fprintln(tw, strings.Join(header, "\t")+"\t"); err != nil {
+		return err
+	}
+
+	for _, bundle := range r.Bundles {
+		row := []string{bundle.Name}
+		for _, component := range Components {
+			row = append(row, FormatSize(bundle.Components[component]))
+		}
+		row = append(row, FormatSize(bundle.Total))
+		if _, err := fmt.Fprintln(tw, strings.Join(row, "\t")+"\t"); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// FormatSize formats a byte count using binary units, for example 1.5 MiB.
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,198 @@
+package appsize
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMeasure(t *testing.T) {
+	bundle := filepath.Join(t.TempDir(), "App.app")
+	files := map[string]int{
+		"App":                              100,
+		"Info.plist":                       10,
+		"Assets.car":                       50,
+		"Base.lproj/Main.storyboardc":      5,
+		"Frameworks/Core.framework/Core":   200,
+		"PlugIns/Widget.appex/Widget":      30,
+		"Extensions/Intents.appex/Intents": 20,
+	}
+	for name, size := range files {
+		pth := filepath.Join(bundle, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(pth, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("App", filepath.Join(bundle, "Link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		executable string
+		want       map[Component]int64
+	}{
+		{
+			name:       "known executable",
+			executable: "App",
+			want: map[Component]int64{
+				ComponentExecutable:     100,
+				ComponentFrameworks:     200,
+				ComponentPlugIns:        50,
+				ComponentAssetCatalogs:  50,
+				ComponentOtherResources: 15,
+			},
+		},
+		{
+			name: "unknown executable",
+			want: map[Component]int64{
+				ComponentExecutable:     0,
+				ComponentFrameworks:     200,
+				ComponentPlugIns:        50,
+				ComponentAssetCatalogs:  50,
+				ComponentOtherResources: 115,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, err := Measure(bundle, tt.executable)
+			if err != nil {
+				t.Fatalf("Measure() error = %v", err)
+			}
+			if size.Name != "App.app" || size.Path != bundle {
+				t.Errorf("Measure() name = %s, path = %s", size.Name, size.Path)
+			}
+			if size.Total != 415 {
+				t.Errorf("Total = %d, want 415", size.Total)
+			}
+			if !reflect.DeepEqual(size.Components, tt.want) {
+				t.Errorf("Components = %v, want %v", size.Components, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportMatch(t *testing.T) {
+	baseline := Report{Bundles: []BundleSize{
+		{Name: "App.app", BundleID: "io.bitrise.App"},
+		{Name: "Watch.app", BundleID: "io.bitrise.App.watchkitapp"},
+		{Name: "Legacy.app"},
+	}}
+
+	tests := []struct {
+		name     string
+		bundle   BundleSize
+		wantName string
+		wantOK   bool
+	}{
+		{name: "same id and name", bundle: BundleSize{Name: "App.app", BundleID: "io.bitrise.App"}, wantName: "App.app", wantOK: true},
+		{name: "renamed artifact", bundle: BundleSize{Name: "App-1.2.3.app", BundleID: "io.bitrise.App"}, wantName: "App.app", wantOK: true},
+		{name: "same name, different id", bundle: BundleSize{Name: "App.app", BundleID: "io.bitrise.Other"}, wantOK: false},
+		{name: "baseline without id", bundle: BundleSize{Name: "Legacy.app", BundleID: "io.bitrise.Legacy"}, wantName: "Legacy.app", wantOK: true},
+		{name: "bundle without id", bundle: BundleSize{Name: "Watch.app"}, wantName: "Watch.app", wantOK: true},
+		{name: "no match", bundle: BundleSize{Name: "Clip.app", BundleID: "io.bitrise.App.Clip"}, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := baseline.Match(tt.bundle)
+			if ok != tt.wantOK || got.Name != tt.wantName {
+				t.Errorf("Match() = %s, %v, want %s, %v", got.Name, ok, tt.wantName, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestReportUnmatched(t *testing.T) {
+	baseline := Report{Bundles: []BundleSize{
+		{Name: "App.app", BundleID: "io.bitrise.App"},
+		{Name: "Watch.app", BundleID: "io.bitrise.App.watchkitapp"},
+		{Name: "Removed.app", BundleID: "io.bitrise.Removed"},
+	}}
+	report := Report{Bundles: []BundleSize{
+		{Name: "App-1.2.3.app", BundleID: "io.bitrise.App"},
+		{Name: "Watch.app", BundleID: "io.bitrise.App.watchkitapp"},
+		{Name: "Clip.app", BundleID: "io.bitrise.App.Clip"},
+	}}
+
+	var got []string
+	for _, bundle := range report.Unmatched(baseline) {
+		got = append(got, bundle.Name)
+	}
+	if want := []string{"Removed.app"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmatched() = %q, want %q", got, want)
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "app_size_report.json")
+	report := Report{Bundles: []BundleSize{{
+		Name:       "App.app",
+		BundleID:   "io.bitrise.App",
+		Path:       "/deploy/App.app",
+		Total:      300,
+		Components: map[Component]int64{ComponentExecutable: 100, ComponentFrameworks: 200},
+	}}}
+	if err := report.WriteJSON(pth); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadReport(pth)
+	if err != nil {
+		t.Fatalf("ReadReport() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, report) {
+		t.Errorf("ReadReport() = %+v, want %+v", got, report)
+	}
+
+	if err := os.WriteFile(pth, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadReport(pth); err == nil {
+		t.Errorf("ReadReport() error = nil for an invalid report, want an error")
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	report := Report{Bundles: []BundleSize{{
+		Name:       "App.app",
+		Total:      3 * 1024 * 1024,
+		Components: map[Component]int64{ComponentExecutable: 1024, ComponentFrameworks: 2 * 1024 * 1024},
+	}}}
+
+	var b strings.Builder
+	if err := report.WriteTable(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Bundle", "executable", "total", "App.app", "1.0 KiB", "2.0 MiB", "3.0 MiB"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("table doesn't contain %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{size: 0, want: "0 B"},
+		{size: 1023, want: "1023 B"},
+		{size: 1024, want: "1.0 KiB"},
+		{size: 1536, want: "1.5 KiB"},
+		{size: 5 * 1024 * 1024, want: "5.0 MiB"},
+		{size: 3 * 1024 * 1024 * 1024, want: "3.0 GiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := FormatSize(tt.size); got != tt.want {
+				t.Errorf("FormatSize(%d) = %s, want %s", tt.size, got, tt.want)
+			}
+		})
+	}
+}
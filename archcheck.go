@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/machoinfo"
+)
+
+const (
+	binaryCheckOff  = "off"
+	binaryCheckWarn = "warn"
+	binaryCheckFail = "fail"
+
+	simulatorArchitecturesAuto = "auto"
+)
+
+// requiredSimulatorArchitectures returns the architectures the simulator binaries need to contain.
+// `auto` means the hardware architecture of the machine running the step (hostArch), as the simulators run natively on it.
+func requiredSimulatorArchitectures(value, hostArch string) []string {
+	if strings.TrimSpace(value) == simulatorArchitecturesAuto {
+		return []string{hostArch}
+	}
+
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n'
+	})
+}
+
+// hostArchitecture returns the hardware architecture of the machine running the step.
+// The step binary itself may be an x86_64 build running under Rosetta on Apple Silicon, where the simulators still run as arm64,
+// so the hardware is queried (`sysctl hw.optional.arm64`, missing on Intel Macs) instead of relying on runtime.GOARCH alone.
+func hostArchitecture() string {
+	out, err := exec.Command("sysctl", "-n", "hw.optional.arm64").Output()
+	arm64Hardware := err == nil && strings.TrimSpace(string(out)) == "1"
+	return hostArchitectureOf(runtime.GOARCH, arm64Hardware)
+}
+
+func hostArchitectureOf(goarch string, arm64Hardware bool) string {
+	if arm64Hardware {
+		return "arm64"
+	}
+	switch goarch {
+	case "amd64":
+		return "x86_64"
+	default:
+		return goarch
+	}
+}
+
+// checkArchitectures prints the slices of every binary of the exported bundles, and reports
+// the binaries missing a required architecture or built for a device platform.
+func checkArchitectures(artifacts []Artifact, requiredArchs []string, mode string) error {
+	var problems []string
+	for _, artifact := range artifacts {
+		log.Printf("%s", filepath.Base(artifact.Path))
+
+		binaries, err := bundleBinaries(artifact.Path, artifact.Info.Executable)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", filepath.Base(artifact.Path), err))
+			continue
+		}
+
+		for _, bundleBinary := range binaries {
+			pth := bundleBinary.Path
+			rel, err := filepath.Rel(filepath.Dir(artifact.Path), pth)
+			if err != nil {
+				rel = pth
+			}
+
+			binary, err := machoinfo.Inspect(pth)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: not a valid Mach-O binary: %s", rel, err))
+				continue
+			}
+			log.Printf("- %s: %s", rel, binary)
+
+			for _, arch := range requiredArchs {
+				if !binary.HasArch(arch) {
+					problems = append(problems, fmt.Sprintf("%s: missing %s slice (has %s)", rel, arch, strings.Join(binary.Arches(), ", ")))
+				}
+			}
+			for _, slice := range binary.Slices {
+				if slice.Platform.IsDevice() {
+					problems = append(problems, fmt.Sprintf("%s: the %s slice is built for a device (%s), not for a simulator", rel, slice.Arch, slice.Platform))
+				}
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		log.Donef("All binaries contain the required architectures (%s)", strings.Join(requiredArchs, ", "))
+		return nil
+	}
+
+	for _, problem := range problems {
+		if mode == binaryCheckFail {
+			log.Errorf("- %s", problem)
+		} else {
+			log.Warnf("- %s", problem)
+		}
+	}
+	if mode == binaryCheckFail {
+		return fmt.Errorf("%d binary architecture problem(s) found", len(problems))
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appbundle"
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/machoinfo"
+)
+
+const (
+	testLoadCmdBuildVersion = 0x32
+	testIOSMinVersion       = 0x110000
+)
+
+// testLoadCommand encodes a load command, padding it to 8 bytes like the linker does.
+func testLoadCommand(cmd uint32, fields []uint32, str string) []byte {
+	var b bytes.Buffer
+	size := 8 + 4*len(fields)
+	if str != "" {
+		size += len(str) + 1
+	}
+	size = (size + 7) &^ 7
+
+	_ = binary.Write(&b, binary.LittleEndian, []uint32{cmd, uint32(size)})
+	_ = binary.Write(&b, binary.LittleEndian, fields)
+	if str != "" {
+		b.WriteString(str)
+	}
+	b.Write(make([]byte, size-b.Len()))
+	return b.Bytes()
+}
+
+func testBuildVersion(platform machoinfo.Platform) []byte {
+	return testLoadCommand(testLoadCmdBuildVersion, []uint32{uint32(platform), testIOSMinVersion, testIOSMinVersion, 0}, "")
+}
+
+// testThinBinary encodes a 64-bit little endian Mach-O executable with the given load commands.
+func testThinBinary(cpu macho.Cpu, cmds ...[]byte) []byte {
+	var commands []byte
+	for _, cmd := range cmds {
+		commands = append(commands, cmd...)
+	}
+
+	var b bytes.Buffer
+	_ = binary.Write(&b, binary.LittleEndian, []uint32{
+		macho.Magic64, uint32(cpu), 0, uint32(macho.TypeExec), uint32(len(cmds)), uint32(len(commands)), 0, 0,
+	})
+	b.Write(commands)
+	return b.Bytes()
+}
+
+// testFatBinary encodes a universal binary of the given thin binaries.
+func testFatBinary(slices ...[]byte) []byte {
+	const align = 12
+	offset := uint32(1 << align)
+
+	var header, content bytes.Buffer
+	_ = binary.Write(&header, binary.BigEndian, []uint32{macho.MagicFat, uint32(len(slices))})
+	for _, slice := range slices {
+		cpu := binary.LittleEndian.Uint32(slice[4:8])
+		subCPU := binary.LittleEndian.Uint32(slice[8:12])
+		_ = binary.Write(&header, binary.BigEndian, []uint32{cpu, subCPU, offset, uint32(len(slice)), align})
+
+		padded := make([]byte, (len(slice)+(1<<align)-1)&^((1<<align)-1))
+		copy(padded, slice)
+		content.Write(padded)
+		offset += uint32(len(padded))
+	}
+
+	out := make([]byte, 1<<align)
+	copy(out, header.Bytes())
+	return append(out, content.Bytes()...)
+}
+
+func writeTestBinary(t *testing.T, pth string, content []byte) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pth, content, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequiredSimulatorArchitectures(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		hostArch string
+		want     []string
+	}{
+		{name: "auto", value: "auto", hostArch: "arm64", want: []string{"arm64"}},
+		{name: "auto with spaces", value: " auto\n", hostArch: "x86_64", want: []string{"x86_64"}},
+		{name: "single architecture", value: "x86_64", hostArch: "arm64", want: []string{"x86_64"}},
+		{name: "comma separated", value: "arm64,x86_64", hostArch: "arm64", want: []string{"arm64", "x86_64"}},
+		{name: "comma and space separated", value: "arm64, x86_64\n", hostArch: "arm64", want: []string{"arm64", "x86_64"}},
+		{name: "empty", value: "", hostArch: "arm64", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requiredSimulatorArchitectures(tt.value, tt.hostArch); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requiredSimulatorArchitectures() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostArchitectureOf(t *testing.T) {
+	tests := []struct {
+		name          string
+		goarch        string
+		arm64Hardware bool
+		want          string
+	}{
+		{name: "Apple Silicon", goarch: "arm64", arm64Hardware: true, want: "arm64"},
+		{name: "x86_64 binary under Rosetta", goarch: "amd64", arm64Hardware: true, want: "arm64"},
+		{name: "Intel", goarch: "amd64", want: "x86_64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hostArchitectureOf(tt.goarch, tt.arm64Hardware); got != tt.want {
+				t.Errorf("hostArchitectureOf() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckArchitectures(t *testing.T) {
+	arm64Simulator := testThinBinary(macho.CpuArm64, testBuildVersion(machoinfo.PlatformIOSSimulator))
+	x86Simulator := testThinBinary(macho.CpuAmd64, testBuildVersion(machoinfo.PlatformIOSSimulator))
+	arm64Device := testThinBinary(macho.CpuArm64, testBuildVersion(machoinfo.PlatformIOS))
+
+	tests := []struct {
+		name          string
+		executable    []byte
+		framework     []byte
+		debugDylib    []byte
+		requiredArchs []string
+		mode          string
+		wantErr       bool
+	}{
+		{
+			name:          "universal binaries",
+			executable:    testFatBinary(arm64Simulator, x86Simulator),
+			framework:     testFatBinary(arm64Simulator, x86Simulator),
+			requiredArchs: []string{"arm64", "x86_64"},
+			mode:          binaryCheckFail,
+		},
+		{
+			name:          "missing architecture in a framework fails",
+			executable:    testFatBinary(arm64Simulator, x86Simulator),
+			framework:     arm64Simulator,
+			requiredArchs: []string{"arm64", "x86_64"},
+			mode:          binaryCheckFail,
+			wantErr:       true,
+		},
+		{
+			name:          "missing architecture in the debug dylib fails",
+			executable:    testFatBinary(arm64Simulator, x86Simulator),
+			framework:     testFatBinary(arm64Simulator, x86Simulator),
+			debugDylib:    x86Simulator,
+			requiredArchs: []string{"arm64", "x86_64"},
+			mode:          binaryCheckFail,
+			wantErr:       true,
+		},
+		{
+			name:          "missing architecture only warns",
+			executable:    arm64Simulator,
+			framework:     arm64Simulator,
+			requiredArchs: []string{"x86_64"},
+			mode:          binaryCheckWarn,
+		},
+		{
+			name:          "device slice fails",
+			executable:    arm64Device,
+			framework:     arm64Simulator,
+			requiredArchs: []string{"arm64"},
+			mode:          binaryCheckFail,
+			wantErr:       true,
+		},
+		{
+			name:          "invalid binary fails",
+			executable:    []byte("not a binary"),
+			framework:     arm64Simulator,
+			requiredArchs: []string{"arm64"},
+			mode:          binaryCheckFail,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appPth := filepath.Join(t.TempDir(), "App.app")
+			writeTestBundle(t, appPth, map[string]interface{}{"CFBundleExecutable": "App"})
+			writeTestBinary(t, filepath.Join(appPth, "App"), tt.executable)
+			writeTestBinary(t, filepath.Join(appPth, "Frameworks", "Lib.framework", "Lib"), tt.framework)
+			if tt.debugDylib != nil {
+				writeTestBinary(t, filepath.Join(appPth, "App.debug.dylib"), tt.debugDylib)
+			}
+
+			artifacts := []Artifact{{Path: appPth, Info: appbundle.Info{Executable: "App"}}}
+			if err := checkArchitectures(artifacts, tt.requiredArchs, tt.mode); (err != nil) != tt.wantErr {
+				t.Errorf("checkArchitectures() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/go-steputils/tools"
+	"github.com/bitrise-io/go-utils/log"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appbundle"
+)
+
+const (
+	embeddedAppModeTopLevel     = "top_level"
+	embeddedAppModeSeparate     = "top_level_and_embedded"
+	embeddedAppModeEmbeddedOnly = "embedded_only"
+)
+
+// kindRanks orders host apps before watch apps, app clips and test runners.
+var kindRanks = map[appbundle.Kind]int{
+	appbundle.KindApp:           0,
+	appbundle.KindExtensionHost: 0,
+	appbundle.KindWatchApp:      1,
+	appbundle.KindAppClip:       2,
+	appbundle.KindTestRunner:    3,
+	appbundle.KindUnknown:       4,
+}
+
+// foundBundle is an app bundle found in the build products.
+// Its Info.plist is read once, when the bundle is found: Info is empty if it can't be read, and InfoErr tells why.
+type foundBundle struct {
+	Path    string
+	Info    appbundle.Info
+	InfoErr error
+}
+
+// findAppBundles returns every .app directory under sourceDir, including the ones embedded in other apps.
+func findAppBundles(sourceDir string) ([]foundBundle, error) {
+	var bundles []foundBundle
+	if err := filepath.WalkDir(sourceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() && filepath.Ext(d.Name()) == ".app" {
+			bundle := foundBundle{Path: path}
+			bundle.Info, bundle.InfoErr = appbundle.ReadInfo(path)
+			if bundle.InfoErr != nil {
+				log.Warnf("Failed to read Info.plist of %s: %s", path, bundle.InfoErr)
+			}
+			bundles = append(bundles, bundle)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to walk through the build products (%s): %s", sourceDir, err)
+	}
+
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("didn't find any app artifacts")
+	}
+
+	return bundles, nil
+}
+
+// selectAppBundles keeps the top-level bundles, the embedded ones (for example Host.app/Watch/X.app) or both,
+// depending on the embedded app mode.
+func selectAppBundles(bundles []foundBundle, mode string) ([]foundBundle, error) {
+	if mode == embeddedAppModeSeparate {
+		return bundles, nil
+	}
+
+	var selected []foundBundle
+	for _, bundle := range bundles {
+		embedded := isEmbeddedBundle(bundle.Path, bundles)
+		if embedded == (mode == embeddedAppModeEmbeddedOnly) {
+			selected = append(selected, bundle)
+		} else {
+			log.Debugf("Skipping %s, embedded app mode: %s", bundle.Path, mode)
+		}
+	}
+
+	if len(selected) == 0 {
+		if mode == embeddedAppModeEmbeddedOnly {
+			return nil, fmt.Errorf("didn't find any embedded app artifacts")
+		}
+		return nil, fmt.Errorf("didn't find any top-level app artifacts")
+	}
+
+	return selected, nil
+}
+
+// embeddedAppHosts maps every embedded bundle to the innermost bundle containing it.
+func embeddedAppHosts(bundles []foundBundle) map[string]string {
+	hosts := map[string]string{}
+	for _, bundle := range bundles {
+		for _, other := range bundles {
+			if other.Path == bundle.Path || !strings.HasPrefix(bundle.Path, other.Path+string(filepath.Separator)) {
+				continue
+			}
+			if len(other.Path) > len(hosts[bundle.Path]) {
+				hosts[bundle.Path] = other.Path
+			}
+		}
+	}
+	return hosts
+}
+
+// mainAppPath returns the path of the scheme's host application, or an empty string if it's unknown.
+//
+// For archives it's the application named by the archive's Info.plist,
+// otherwise the top-level app named after the scheme's product.
+func mainAppPath(bundles []foundBundle, archivePth, productName string) string {
+	if archivePth != "" {
+		pth, err := appbundle.ArchiveApplicationPath(archivePth)
+		if err == nil {
+			return pth
+		}
+		log.Warnf("Failed to read the main application from the archive's Info.plist: %s", err)
+	}
+
+	if productName == "" {
+		return ""
+	}
+	for _, bundle := range bundles {
+		if filepath.Base(bundle.Path) != productName+".app" || isEmbeddedBundle(bundle.Path, bundles) {
+			continue
+		}
+		switch appbundle.Classify(bundle.Path, bundle.Info) {
+		case appbundle.KindApp, appbundle.KindExtensionHost:
+			return bundle.Path
+		}
+	}
+	return ""
+}
+
+// orderAppBundles returns the bundles with the main (host) application first.
+//
+// Top-level bundles come before embedded ones, and host apps before watch apps, app clips and test runners.
+// Ties are broken by the scheme's product name, then by path.
+func orderAppBundles(bundles []foundBundle, mainAppPth, productName string) []foundBundle {
+	type rankedBundle struct {
+		bundle    foundBundle
+		isMain    bool
+		embedded  bool
+		kind      int
+		nameMatch bool
+	}
+
+	var ranked []rankedBundle
+	for _, bundle := range bundles {
+		ranked = append(ranked, rankedBundle{
+			bundle:    bundle,
+			isMain:    mainAppPth != "" && bundle.Path == mainAppPth,
+			embedded:  isEmbeddedBundle(bundle.Path, bundles),
+			kind:      kindRanks[appbundle.Classify(bundle.Path, bundle.Info)],
+			nameMatch: productName != "" && filepath.Base(bundle.Path) == productName+".app",
+		})
+	}
+
+	slices.SortStableFunc(ranked, func(a, b rankedBundle) int {
+		switch {
+		case a.isMain != b.isMain:
+			return boolOrder(a.isMain)
+		case a.embedded != b.embedded:
+			return -boolOrder(a.embedded)
+		case a.kind != b.kind:
+			return a.kind - b.kind
+		case a.nameMatch != b.nameMatch:
+			return boolOrder(a.nameMatch)
+		}
+		return strings.Compare(a.bundle.Path, b.bundle.Path)
+	})
+
+	var ordered []foundBundle
+	for _, r := range ranked {
+		ordered = append(ordered, r.bundle)
+	}
+	return ordered
+}
+
+func isEmbeddedBundle(bundlePth string, bundles []foundBundle) bool {
+	for _, other := range bundles {
+		if other.Path != bundlePth && strings.HasPrefix(bundlePth, other.Path+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// boolOrder sorts true values first.
+func boolOrder(b bool) int {
+	if b {
+		return -1
+	}
+	return 1
+}
+
+// Roles of the exported apps in the JSON app list.
+const (
+	appRoleMain      = "main"
+	appRoleEmbedded  = "embedded"
+	appRoleExtension = "extension"
+)
+
+type appListEntry struct {
+	Path    string `json:"path"`
+	ZipPath string `json:"zip_path,omitempty"`
+	Role    string `json:"role"`
+}
+
+// appRole tells how an exported app relates to the scheme's host app (mainAppPth):
+// apps found inside another app are embedded, the other top-level apps built alongside the host
+// (for example a standalone watch app or an app clip) extend it.
+func appRole(bundlePth, mainAppPth string, embedded bool) string {
+	switch {
+	case mainAppPth != "" && bundlePth == mainAppPth:
+		return appRoleMain
+	case embedded:
+		return appRoleEmbedded
+	}
+	return appRoleExtension
+}
+
+// exportAppListJSON exports every app with its zip and role as a JSON array,
+// in the order of BITRISE_APP_DIR_PATH_LIST.
+func exportAppListJSON(artifacts []Artifact) (string, error) {
+	entries := []appListEntry{}
+	for _, artifact := range artifacts {
+		entry := appListEntry{
+			Path: artifact.Path,
+			Role: artifact.Role,
+		}
+		for _, pth := range artifact.Packages {
+			if filepath.Ext(pth) == ".zip" {
+				entry.ZipPath = pth
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	content, err := json.Marshal(entries)
+	if err != nil {
+		return "", err
+	}
+	if err := tools.ExportEnvironmentWithEnvman(bitriseAppDirPathListJSONKey, string(content)); err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"howett.net/plist"
+)
+
+// writeTestBundle creates an app bundle with the given Info.plist.
+// A nil info writes a bundle without an Info.plist.
+func writeTestBundle(t *testing.T, pth string, info map[string]interface{}) {
+	t.Helper()
+
+	if err := os.MkdirAll(pth, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		return
+	}
+
+	content, err := plist.Marshal(info, plist.XMLFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pth, "Info.plist"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindAppBundles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBundle(t, filepath.Join(dir, "App.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App", "CFBundlePackageType": "APPL"})
+	writeTestBundle(t, filepath.Join(dir, "App.app", "Watch", "Watch.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App.watchkitapp", "WKApplication": true})
+	writeTestBundle(t, filepath.Join(dir, "Broken.app"), nil)
+	writeTestBundle(t, filepath.Join(dir, "App.app", "PlugIns", "Widget.appex"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App.Widget"})
+
+	bundles, err := findAppBundles(dir)
+	if err != nil {
+		t.Fatalf("findAppBundles() error = %v", err)
+	}
+
+	want := []struct {
+		path     string
+		bundleID string
+		infoErr  bool
+	}{
+		{path: filepath.Join(dir, "App.app"), bundleID: "io.bitrise.App"},
+		{path: filepath.Join(dir, "App.app", "Watch", "Watch.app"), bundleID: "io.bitrise.App.watchkitapp"},
+		{path: filepath.Join(dir, "Broken.app"), infoErr: true},
+	}
+	if len(bundles) != len(want) {
+		t.Fatalf("findAppBundles() found %d bundles, want %d: %+v", len(bundles), len(want), bundles)
+	}
+	for i, w := range want {
+		got := bundles[i]
+		if got.Path != w.path {
+			t.Errorf("bundles[%d].Path = %s, want %s", i, got.Path, w.path)
+		}
+		if got.Info.BundleID != w.bundleID {
+			t.Errorf("bundles[%d].Info.BundleID = %s, want %s", i, got.Info.BundleID, w.bundleID)
+		}
+		if (got.InfoErr != nil) != w.infoErr {
+			t.Errorf("bundles[%d].InfoErr = %v, want error: %v", i, got.InfoErr, w.infoErr)
+		}
+	}
+
+	if _, err := findAppBundles(t.TempDir()); err == nil {
+		t.Errorf("findAppBundles() error = nil for a dir without bundles, want an error")
+	}
+
+	// The scheme's product is searched in the build dir, the other (stale) bundles next to it are not found.
+	productBundles, err := findAppBundles(filepath.Join(dir, "App.app"))
+	if err != nil {
+		t.Fatalf("findAppBundles() error = %v", err)
+	}
+	var got []string
+	for _, bundle := range productBundles {
+		got = append(got, bundle.Path)
+	}
+	if want := []string{filepath.Join(dir, "App.app"), filepath.Join(dir, "App.app", "Watch", "Watch.app")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findAppBundles() of the product = %q, want %q", got, want)
+	}
+}
+
+func TestSelectAppBundles(t *testing.T) {
+	bundles := []foundBundle{
+		{Path: "/build/App.app"},
+		{Path: "/build/App.app/Watch/Watch.app"},
+		{Path: "/build/App.app/AppClips/Clip.app"},
+		{Path: "/build/Other.app"},
+		{Path: "/build/AppTests.app"},
+	}
+
+	tests := []struct {
+		name    string
+		mode    string
+		bundles []foundBundle
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "top level",
+			mode:    embeddedAppModeTopLevel,
+			bundles: bundles,
+			want:    []string{"/build/App.app", "/build/Other.app", "/build/AppTests.app"},
+		},
+		{
+			name:    "top level and embedded",
+			mode:    embeddedAppModeSeparate,
+			bundles: bundles,
+			want:    []string{"/build/App.app", "/build/App.app/Watch/Watch.app", "/build/App.app/AppClips/Clip.app", "/build/Other.app", "/build/AppTests.app"},
+		},
+		{
+			name:    "embedded only",
+			mode:    embeddedAppModeEmbeddedOnly,
+			bundles: bundles,
+			want:    []string{"/build/App.app/Watch/Watch.app", "/build/App.app/AppClips/Clip.app"},
+		},
+		{
+			name:    "embedded only without embedded apps",
+			mode:    embeddedAppModeEmbeddedOnly,
+			bundles: []foundBundle{{Path: "/build/App.app"}},
+			wantErr: true,
+		},
+		{
+			name:    "prefix of another bundle's name is not a host",
+			mode:    embeddedAppModeTopLevel,
+			bundles: []foundBundle{{Path: "/build/App.app"}, {Path: "/build/App.app2/Watch.app"}},
+			want:    []string{"/build/App.app", "/build/App.app2/Watch.app"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := selectAppBundles(tt.bundles, tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectAppBundles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, bundle := range selected {
+				got = append(got, bundle.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectAppBundles() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedAppHosts(t *testing.T) {
+	bundles := []foundBundle{
+		{Path: "/build/App.app"},
+		{Path: "/build/App.app/Watch/Watch.app"},
+		{Path: "/build/App.app/Watch/Watch.app/Nested/Nested.app"},
+		{Path: "/build/App.app/AppClips/Clip.app"},
+		{Path: "/build/Other.app"},
+	}
+
+	want := map[string]string{
+		"/build/App.app/Watch/Watch.app":                   "/build/App.app",
+		"/build/App.app/Watch/Watch.app/Nested/Nested.app": "/build/App.app/Watch/Watch.app",
+		"/build/App.app/AppClips/Clip.app":                 "/build/App.app",
+	}
+	if got := embeddedAppHosts(bundles); !reflect.DeepEqual(got, want) {
+		t.Errorf("embeddedAppHosts() = %v, want %v", got, want)
+	}
+}
+
+func TestMainAppPath(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBundle(t, filepath.Join(dir, "App.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App", "CFBundlePackageType": "APPL"})
+	writeTestBundle(t, filepath.Join(dir, "App.app", "Watch", "Watch.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App.watchkitapp", "CFBundlePackageType": "APPL", "WKApplication": true})
+	writeTestBundle(t, filepath.Join(dir, "Watch.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.Watch", "CFBundlePackageType": "APPL", "WKApplication": true})
+	writeTestBundle(t, filepath.Join(dir, "Other.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.Other", "CFBundlePackageType": "APPL"})
+	bundles, err := findAppBundles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "App.xcarchive")
+	writeTestBundle(t, archive, map[string]interface{}{"ApplicationProperties": map[string]interface{}{"ApplicationPath": "Applications/Other.app"}})
+
+	tests := []struct {
+		name        string
+		archivePth  string
+		productName string
+		want        string
+	}{
+		{name: "archive application", archivePth: archive, productName: "App", want: filepath.Join(archive, "Products", "Applications", "Other.app")},
+		{name: "product name", productName: "App", want: filepath.Join(dir, "App.app")},
+		{name: "product name of a watch app", productName: "Watch"},
+		{name: "product name of no app", productName: "Missing"},
+		{name: "unknown product name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mainAppPath(bundles, tt.archivePth, tt.productName); got != tt.want {
+				t.Errorf("mainAppPath() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppRole(t *testing.T) {
+	dir := t.TempDir()
+	app := map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App", "CFBundlePackageType": "APPL"}
+	writeTestBundle(t, filepath.Join(dir, "App.app"), app)
+	writeTestBundle(t, filepath.Join(dir, "App.app", "Watch", "Watch.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App.watchkitapp", "CFBundlePackageType": "APPL"})
+	writeTestBundle(t, filepath.Join(dir, "App.app", "AppClips", "Clip.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App.Clip", "CFBundlePackageType": "APPL"})
+	writeTestBundle(t, filepath.Join(dir, "App.app", "Frameworks", "Helper.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App.Helper", "CFBundlePackageType": "APPL"})
+	writeTestBundle(t, filepath.Join(dir, "App.app", "Frameworks", "Companion.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App.Companion", "CFBundlePackageType": "APPL", "WKApplication": true})
+	writeTestBundle(t, filepath.Join(dir, "Watch.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.Watch", "CFBundlePackageType": "APPL", "WKApplication": true})
+	writeTestBundle(t, filepath.Join(dir, "Clip.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.Clip", "CFBundlePackageType": "APPL", "NSAppClip": map[string]interface{}{}})
+	writeTestBundle(t, filepath.Join(dir, "AppUITests-Runner.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.AppUITests.xctrunner", "CFBundlePackageType": "APPL"})
+	writeTestBundle(t, filepath.Join(dir, "Other.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.Other", "CFBundlePackageType": "APPL"})
+	bundles, err := findAppBundles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		mainAppPth string
+		want       map[string]string
+	}{
+		{
+			name:       "host app exported",
+			mainAppPth: filepath.Join(dir, "App.app"),
+			want: map[string]string{
+				"App.app":                          appRoleMain,
+				"App.app/AppClips/Clip.app":        appRoleEmbedded,
+				"App.app/Frameworks/Companion.app": appRoleEmbedded,
+				"App.app/Frameworks/Helper.app":    appRoleEmbedded,
+				"App.app/Watch/Watch.app":          appRoleEmbedded,
+				"AppUITests-Runner.app":            appRoleExtension,
+				"Clip.app":                         appRoleExtension,
+				"Other.app":                        appRoleExtension,
+				"Watch.app":                        appRoleExtension,
+			},
+		},
+		{
+			name: "host app unknown",
+			want: map[string]string{
+				"App.app":                          appRoleExtension,
+				"App.app/AppClips/Clip.app":        appRoleEmbedded,
+				"App.app/Frameworks/Companion.app": appRoleEmbedded,
+				"App.app/Frameworks/Helper.app":    appRoleEmbedded,
+				"App.app/Watch/Watch.app":          appRoleEmbedded,
+				"AppUITests-Runner.app":            appRoleExtension,
+				"Clip.app":                         appRoleExtension,
+				"Other.app":                        appRoleExtension,
+				"Watch.app":                        appRoleExtension,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts := embeddedAppHosts(bundles)
+			got := map[string]string{}
+			for _, bundle := range bundles {
+				rel, err := filepath.Rel(dir, bundle.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, embedded := hosts[bundle.Path]
+				got[filepath.ToSlash(rel)] = appRole(bundle.Path, tt.mainAppPth, embedded)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("appRole() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
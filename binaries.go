@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appbundle"
+)
+
+// bundleBinary is a Mach-O binary of a bundle and the main executable of the process loading it.
+type bundleBinary struct {
+	Path       string
+	Executable string
+}
+
+// bundleBinaries returns the Mach-O binaries of a bundle: its main executable (first), the dylibs next to it
+// (like the `<App>.debug.dylib` of debug builds), the embedded frameworks and dylibs, and the binaries of its app extensions.
+// App extensions run in their own process, so their binaries are loaded by the extension's executable.
+// executableName is the bundle's CFBundleExecutable, if it's already known.
+func bundleBinaries(bundlePth, executableName string) ([]bundleBinary, error) {
+	executable, err := bundleExecutable(bundlePth, executableName)
+	if err != nil {
+		return nil, err
+	}
+	binaries := []bundleBinary{{Path: executable, Executable: executable}}
+
+	var dylibs []string
+	for _, pattern := range []string{
+		filepath.Join(bundlePth, "*.dylib"),
+		filepath.Join(bundlePth, "Frameworks", "*.dylib"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		dylibs = append(dylibs, matches...)
+	}
+
+	frameworks, err := filepath.Glob(filepath.Join(bundlePth, "Frameworks", "*.framework"))
+	if err != nil {
+		return nil, err
+	}
+	for _, framework := range frameworks {
+		frameworkExecutable, err := bundleExecutable(framework, "")
+		if err != nil {
+			return nil, err
+		}
+		dylibs = append(dylibs, frameworkExecutable)
+	}
+
+	for _, dylib := range dylibs {
+		binaries = append(binaries, bundleBinary{Path: dylib, Executable: executable})
+	}
+
+	extensions, err := filepath.Glob(filepath.Join(bundlePth, "PlugIns", "*.appex"))
+	if err != nil {
+		return nil, err
+	}
+	for _, extension := range extensions {
+		extensionBinaries, err := bundleBinaries(extension, "")
+		if err != nil {
+			return nil, err
+		}
+		binaries = append(binaries, extensionBinaries...)
+	}
+
+	return binaries, nil
+}
+
+// bundleExecutable returns the path of the bundle's CFBundleExecutable (read from the Info.plist if name is empty),
+// falling back to the bundle's name if the Info.plist doesn't name it.
+func bundleExecutable(bundlePth, name string) (string, error) {
+	if name == "" {
+		if info, err := appbundle.ReadInfo(bundlePth); err == nil {
+			name = info.Executable
+		}
+	}
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(bundlePth), filepath.Ext(bundlePth))
+	}
+
+	pth := filepath.Join(bundlePth, name)
+	if _, err := os.Stat(pth); err != nil {
+		return "", fmt.Errorf("executable of %s not found: %s", filepath.Base(bundlePth), err)
+	}
+	return pth, nil
+}
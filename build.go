@@ -1,8 +1,8 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -11,56 +11,47 @@ import (
 
 	"github.com/bitrise-io/bitrise-build-cache-cli/v2/pkg/reactnative/wrap"
 	"github.com/bitrise-io/go-utils/colorstring"
-	"github.com/bitrise-io/go-utils/command"
 	v2log "github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-xcode/xcodebuild"
 	"github.com/bitrise-io/go-xcode/xcpretty"
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/buildlog"
 	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/util"
 )
 
-func prepareCommand(xcodeCmd *xcodebuild.CommandBuilder, useXcpretty bool, output *bytes.Buffer) (*command.Model, *xcpretty.CommandModel) {
-	if useXcpretty {
-		return nil, xcpretty.New(xcodeCmd)
-	}
-
-	buildRootCmd := xcodeCmd.Command()
-	buildRootCmd.SetStdout(io.MultiWriter(os.Stdout, output))
-	buildRootCmd.SetStderr(io.MultiWriter(os.Stderr, output))
-	return buildRootCmd, nil
-}
-
-func runCommand(buildCmd *xcodebuild.CommandBuilder, useXcpretty bool) (string, error) {
+// runCommand runs the xcodebuild command and streams its raw stdout/stderr
+// into output, so the full log never has to be held in memory.
+func runCommand(buildCmd *xcodebuild.CommandBuilder, useXcpretty bool, output io.Writer) error {
 	// When React Native build cache is active on this machine, route the
 	// xcodebuild invocation through `bitrise-build-cache react-native run -- ...`
 	// so it runs as a child of the active RN parent invocation. xcpretty piping
-	// is preserved when the user picked xcpretty as the output tool — we just
-	// pipe the wrapped command's stdout into xcpretty manually instead of
-	// letting go-xcode's xcpretty.New build the pipeline. When RN cache is not
-	// active, fall through to the existing v1 path unchanged.
+	// is preserved when the user picked xcpretty as the output tool. When RN
+	// cache is not active, xcodebuild is run directly.
 	det := wrap.Detect(context.Background(), wrap.DetectParams{Logger: v2log.NewLogger()})
 	if det.ReactNativeEnabled {
-		return runWithRNWrap(buildCmd, det, useXcpretty)
+		return runWithRNWrap(buildCmd, det, useXcpretty, output)
 	}
 
-	var output bytes.Buffer
-	xcodebuildCmd, xcprettyCmd := prepareCommand(buildCmd, useXcpretty, &output)
+	xcodebuildCmd := buildCmd.ExecCommand()
 
-	if xcprettyCmd != nil {
-		util.LogWithTimestamp(colorstring.Green, "$ %s", xcprettyCmd.PrintableCmd())
+	if useXcpretty {
+		util.LogWithTimestamp(colorstring.Green, "$ %s", xcpretty.New(buildCmd).PrintableCmd())
 		fmt.Println()
-		return xcprettyCmd.Run()
+		return runWithXcpretty(xcodebuildCmd, output)
 	}
-	util.LogWithTimestamp(colorstring.Green, "$ %s", xcodebuildCmd.PrintableCommandArgs())
+
+	util.LogWithTimestamp(colorstring.Green, "$ %s", buildCmd.PrintableCmd())
 	fmt.Println()
-	return output.String(), xcodebuildCmd.Run()
+
+	flushOutput := teeOutput(xcodebuildCmd, os.Stdout, os.Stderr, output)
+	runErr := xcodebuildCmd.Run()
+	return errors.Join(runErr, flushOutput())
 }
 
 // runWithRNWrap runs xcodebuild under `bitrise-build-cache react-native run --`,
 // preserving xcpretty piping when useXcpretty is set. Combined raw xcodebuild
-// stdout/stderr is captured into the returned string for the existing
-// log-parsing path; xcpretty consumes the same stdout for prettified terminal
-// output.
-func runWithRNWrap(buildCmd *xcodebuild.CommandBuilder, det wrap.Detection, useXcpretty bool) (string, error) {
+// stdout/stderr is streamed into output for the log-parsing path; xcpretty
+// consumes the same stdout for prettified terminal output.
+func runWithRNWrap(buildCmd *xcodebuild.CommandBuilder, det wrap.Detection, useXcpretty bool, output io.Writer) error {
 	args := append([]string{"xcodebuild"}, buildCmd.CommandArgs()...)
 	name, wrappedArgs := wrap.Wrap(det, args[0], args[1:])
 	displayArgs := append([]string{name}, wrappedArgs...)
@@ -68,23 +59,25 @@ func runWithRNWrap(buildCmd *xcodebuild.CommandBuilder, det wrap.Detection, useX
 	util.LogWithTimestamp(colorstring.Green, "$ %s", strings.Join(displayArgs, " "))
 	fmt.Println()
 
-	var output bytes.Buffer
 	xcCmd := exec.Command(name, wrappedArgs...) //nolint:gosec
 
 	if !useXcpretty {
-		xcCmd.Stdout = io.MultiWriter(os.Stdout, &output)
-		xcCmd.Stderr = io.MultiWriter(os.Stderr, &output)
+		flushOutput := teeOutput(xcCmd, os.Stdout, os.Stderr, output)
+		runErr := xcCmd.Run()
 
-		return output.String(), xcCmd.Run()
+		return errors.Join(runErr, flushOutput())
 	}
 
-	// xcpretty pipeline: xcodebuild stdout → xcpretty stdin, while we tee the
-	// raw output into `output` so callers can scan it for distribution-log
-	// pointers etc. xcodebuild stderr also goes into `output` and to user stderr.
+	return runWithXcpretty(xcCmd, output)
+}
+
+// runWithXcpretty pipes xcodebuild stdout into xcpretty's stdin, while the raw
+// output is teed into output so callers can scan it for build issues.
+// xcodebuild stderr also goes into output and to user stderr.
+func runWithXcpretty(xcCmd *exec.Cmd, output io.Writer) error {
 	xcprettyCmd := exec.Command("xcpretty") //nolint:gosec
 	pr, pw := io.Pipe()
-	xcCmd.Stdout = io.MultiWriter(pw, &output)
-	xcCmd.Stderr = io.MultiWriter(os.Stderr, &output)
+	flushOutput := teeOutput(xcCmd, pw, os.Stderr, output)
 	xcprettyCmd.Stdin = pr
 	xcprettyCmd.Stdout = os.Stdout
 	xcprettyCmd.Stderr = os.Stderr
@@ -92,19 +85,35 @@ func runWithRNWrap(buildCmd *xcodebuild.CommandBuilder, det wrap.Detection, useX
 	if err := xcprettyCmd.Start(); err != nil {
 		_ = pw.Close()
 
-		return "", fmt.Errorf("start xcpretty: %w", err)
+		return fmt.Errorf("start xcpretty: %w", err)
 	}
 
 	runErr := xcCmd.Run()
 	_ = pw.Close()
 	waitErr := xcprettyCmd.Wait()
+	flushErr := flushOutput()
 
 	if runErr != nil {
-		return output.String(), runErr
+		return runErr
 	}
 	if waitErr != nil {
-		return output.String(), waitErr
+		return waitErr
 	}
 
-	return output.String(), nil
+	return flushErr
+}
+
+// teeOutput sets the command's stdout and stderr, and tees both streams into output.
+// Each stream is buffered line by line before it reaches output, so the partial lines of the two streams
+// don't interleave in the log. The returned func writes the streams' unterminated last lines,
+// call it after the command exited.
+func teeOutput(cmd *exec.Cmd, stdout, stderr, output io.Writer) func() error {
+	stdoutLines := buildlog.NewLineWriter(output)
+	stderrLines := buildlog.NewLineWriter(output)
+	cmd.Stdout = io.MultiWriter(stdout, stdoutLines)
+	cmd.Stderr = io.MultiWriter(stderr, stderrLines)
+
+	return func() error {
+		return errors.Join(stdoutLines.Flush(), stderrLines.Flush())
+	}
 }
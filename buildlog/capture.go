@@ -0,0 +1,188 @@
+package buildlog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// maxLineLength limits the length of a line kept in memory for parsing, longer lines are truncated.
+const maxLineLength = 16 * 1024 * 1024
+
+// Capture streams the raw xcodebuild output into a log file.
+// Only the tail of the output and the parsed issues are kept in memory.
+type Capture struct {
+	mu sync.Mutex
+
+	file   *os.File
+	tail   *ringBuffer
+	parser *Parser
+
+	partialLine []byte
+}
+
+// NewCapture creates (or truncates) the log file at pth.
+// tailSize is the number of bytes kept in memory from the end of the output.
+func NewCapture(pth string, tailSize int) (*Capture, error) {
+	file, err := os.Create(pth)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Capture{
+		file:   file,
+		tail:   newRingBuffer(tailSize),
+		parser: NewParser(),
+	}, nil
+}
+
+// Write is safe to call from the stdout and stderr copier goroutines at the same time.
+func (c *Capture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tail.Write(p)
+	c.parseLines(p)
+
+	return c.file.Write(p)
+}
+
+// Close flushes the last, unterminated line to the parser and closes the log file.
+func (c *Capture) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.partialLine) > 0 {
+		c.parser.ParseLine(string(c.partialLine))
+		c.partialLine = nil
+	}
+
+	return c.file.Close()
+}
+
+// Tail returns the last bytes of the output.
+func (c *Capture) Tail() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return string(c.tail.Bytes())
+}
+
+// Report returns the issues parsed from the output so far.
+func (c *Capture) Report() Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.parser.Report()
+}
+
+func (c *Capture) parseLines(p []byte) {
+	for len(p) > 0 {
+		idx := bytes.IndexByte(p, '\n')
+		if idx < 0 {
+			if len(c.partialLine)+len(p) <= maxLineLength {
+				c.partialLine = append(c.partialLine, p...)
+			}
+			return
+		}
+
+		line := p[:idx]
+		if len(c.partialLine) > 0 {
+			if len(c.partialLine)+len(line) <= maxLineLength {
+				line = append(c.partialLine, line...)
+			} else {
+				line = c.partialLine
+			}
+			c.partialLine = nil
+		}
+		c.parser.ParseLine(string(line))
+
+		p = p[idx+1:]
+	}
+}
+
+// LineWriter buffers the output of a single stream, and writes only complete lines into the underlying writer.
+// Give every stream its own LineWriter, so the partial lines of the streams don't interleave.
+type LineWriter struct {
+	w       io.Writer
+	pending []byte
+}
+
+// NewLineWriter creates a LineWriter writing into w.
+func NewLineWriter(w io.Writer) *LineWriter {
+	return &LineWriter{w: w}
+}
+
+// Write writes the complete lines of the buffered output and p, and buffers the rest.
+// A line longer than maxLineLength is written without waiting for its end.
+func (l *LineWriter) Write(p []byte) (int, error) {
+	l.pending = append(l.pending, p...)
+
+	end := bytes.LastIndexByte(l.pending, '\n') + 1
+	if end == 0 && len(l.pending) <= maxLineLength {
+		return len(p), nil
+	}
+	if end == 0 {
+		end = len(l.pending)
+	}
+
+	_, err := l.w.Write(l.pending[:end])
+	l.pending = append(l.pending[:0], l.pending[end:]...)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes the buffered, unterminated last line.
+func (l *LineWriter) Flush() error {
+	if len(l.pending) == 0 {
+		return nil
+	}
+
+	_, err := l.w.Write(l.pending)
+	l.pending = nil
+	return err
+}
+
+type ringBuffer struct {
+	buf  []byte
+	pos  int
+	full bool
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{buf: make([]byte, size)}
+}
+
+func (r *ringBuffer) Write(p []byte) {
+	size := len(r.buf)
+	if size == 0 {
+		return
+	}
+
+	if len(p) >= size {
+		copy(r.buf, p[len(p)-size:])
+		r.pos = 0
+		r.full = true
+		return
+	}
+
+	n := copy(r.buf[r.pos:], p)
+	if n < len(p) {
+		copy(r.buf, p[n:])
+		r.full = true
+	}
+	r.pos = (r.pos + len(p)) % size
+	if r.pos == 0 && len(p) > 0 {
+		r.full = true
+	}
+}
+
+func (r *ringBuffer) Bytes() []byte {
+	if !r.full {
+		return append([]byte{}, r.buf[:r.pos]...)
+	}
+	return append(append([]byte{}, r.buf[r.pos:]...), r.buf[:r.pos]...)
+}
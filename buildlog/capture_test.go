@@ -0,0 +1,209 @@
+package buildlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRingBuffer(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		writes []string
+		want   string
+	}{
+		{name: "empty", size: 4, want: ""},
+		{name: "zero size", size: 0, writes: []string{"abc"}, want: ""},
+		{name: "not full", size: 8, writes: []string{"ab", "cd"}, want: "abcd"},
+		{name: "exactly full", size: 4, writes: []string{"ab", "cd"}, want: "abcd"},
+		{name: "wraps around", size: 4, writes: []string{"abc", "de"}, want: "bcde"},
+		{name: "wraps several times", size: 3, writes: []string{"ab", "cd", "ef", "g"}, want: "efg"},
+		{name: "write larger than the buffer", size: 3, writes: []string{"a", "bcdefg"}, want: "efg"},
+		{name: "write larger after wrap", size: 3, writes: []string{"ab", "cd", "wxyz"}, want: "xyz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRingBuffer(tt.size)
+			for _, w := range tt.writes {
+				r.Write([]byte(w))
+			}
+			if got := string(r.Bytes()); got != tt.want {
+				t.Errorf("Bytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapture(t *testing.T) {
+	tests := []struct {
+		name       string
+		chunks     []string
+		wantErrors []string
+	}{
+		{
+			name:       "lines split across writes",
+			chunks:     []string{"/src/a.swift:1:2: err", "or: boom\nerror: sec", "ond\n"},
+			wantErrors: []string{"boom", "second"},
+		},
+		{
+			name:       "unterminated last line is parsed on close",
+			chunks:     []string{"error: first\n", "error: last"},
+			wantErrors: []string{"first", "last"},
+		},
+		{
+			name:       "several lines in one write",
+			chunks:     []string{"error: one\r\nnoise\nerror: two\n"},
+			wantErrors: []string{"one", "two"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := filepath.Join(t.TempDir(), "raw.log")
+			c, err := NewCapture(pth, 8)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, chunk := range tt.chunks {
+				if _, err := c.Write([]byte(chunk)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := c.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, issue := range c.Report().Errors {
+				got = append(got, issue.Message)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.wantErrors, "|") {
+				t.Errorf("errors = %q, want %q", got, tt.wantErrors)
+			}
+
+			all := strings.Join(tt.chunks, "")
+			content, err := os.ReadFile(pth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != all {
+				t.Errorf("log file = %q, want %q", content, all)
+			}
+			if want := all[len(all)-8:]; c.Tail() != want {
+				t.Errorf("Tail() = %q, want %q", c.Tail(), want)
+			}
+		})
+	}
+}
+
+func TestCaptureConcurrentWrites(t *testing.T) {
+	c, err := NewCapture(filepath.Join(t.TempDir(), "raw.log"), 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for _, line := range []string{"error: stdout\n", "error: stderr\n"} {
+		wg.Add(1)
+		go func(line string) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_, _ = c.Write([]byte(line))
+			}
+		}(line)
+	}
+	wg.Wait()
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(c.Report().Errors); got != 2 {
+		t.Errorf("len(errors) = %d, want 2", got)
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	type write struct {
+		stream int
+		chunk  string
+	}
+	tests := []struct {
+		name       string
+		writes     []write
+		wantErrors []string
+		wantLog    string
+	}{
+		{
+			name: "partial lines of the streams don't interleave",
+			writes: []write{
+				{stream: 0, chunk: "/src/a.swift:1:2: err"},
+				{stream: 1, chunk: "error: from "},
+				{stream: 0, chunk: "or: from stdout\n"},
+				{stream: 1, chunk: "stderr\n"},
+			},
+			wantErrors: []string{"from stdout", "from stderr"},
+			wantLog:    "/src/a.swift:1:2: error: from stdout\nerror: from stderr\n",
+		},
+		{
+			name: "several lines in one write",
+			writes: []write{
+				{stream: 0, chunk: "error: one\nerror: tw"},
+				{stream: 1, chunk: "warning: other\n"},
+				{stream: 0, chunk: "o\n"},
+			},
+			wantErrors: []string{"one", "two"},
+			wantLog:    "error: one\nwarning: other\nerror: two\n",
+		},
+		{
+			name: "unterminated last line is written on flush",
+			writes: []write{
+				{stream: 0, chunk: "error: one\n"},
+				{stream: 1, chunk: "warning: other\n"},
+				{stream: 0, chunk: "error: last"},
+			},
+			wantErrors: []string{"one", "last"},
+			wantLog:    "error: one\nwarning: other\nerror: last",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := filepath.Join(t.TempDir(), "raw.log")
+			c, err := NewCapture(pth, 1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+			streams := []*LineWriter{NewLineWriter(c), NewLineWriter(c)}
+			for _, w := range tt.writes {
+				if n, err := streams[w.stream].Write([]byte(w.chunk)); err != nil || n != len(w.chunk) {
+					t.Fatalf("Write() = %d, %v, want %d, nil", n, err, len(w.chunk))
+				}
+			}
+			for _, stream := range streams {
+				if err := stream.Flush(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := c.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, issue := range c.Report().Errors {
+				got = append(got, issue.Message)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.wantErrors, "|") {
+				t.Errorf("errors = %q, want %q", got, tt.wantErrors)
+			}
+
+			content, err := os.ReadFile(pth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.wantLog {
+				t.Errorf("log file = %q, want %q", content, tt.wantLog)
+			}
+		})
+	}
+}
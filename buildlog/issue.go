@@ -0,0 +1,60 @@
+package buildlog
+
+import "fmt"
+
+// Severity ...
+type Severity string
+
+// Severities reported by xcodebuild.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Kind tells which build phase produced the issue.
+type Kind string
+
+// Issue kinds.
+const (
+	KindCompiler    Kind = "compiler"
+	KindLinker      Kind = "linker"
+	KindScriptPhase Kind = "script_phase"
+	KindBuild       Kind = "build"
+)
+
+// Issue is a single error or warning found in the xcodebuild log.
+type Issue struct {
+	Kind     Kind     `json:"kind"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Message  string   `json:"message"`
+	Target   string   `json:"target,omitempty"`
+	Project  string   `json:"project,omitempty"`
+}
+
+// String formats the issue the way Xcode prints diagnostics.
+func (i Issue) String() string {
+	location := ""
+	if i.File != "" {
+		location = i.File
+		if i.Line > 0 {
+			location += fmt.Sprintf(":%d", i.Line)
+			if i.Column > 0 {
+				location += fmt.Sprintf(":%d", i.Column)
+			}
+		}
+		location += ": "
+	}
+
+	s := fmt.Sprintf("%s%s: %s", location, i.Severity, i.Message)
+	if i.Target != "" {
+		s += fmt.Sprintf(" (in target '%s')", i.Target)
+	}
+	return s
+}
+
+func (i Issue) key() string {
+	return fmt.Sprintf("%s|%s|%s|%d|%d|%s|%s", i.Kind, i.Severity, i.File, i.Line, i.Column, i.Message, i.Target)
+}
@@ -0,0 +1,177 @@
+package buildlog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// /path/to/File.swift:12:5: error: cannot find 'foo' in scope
+	diagnosticPattern = regexp.MustCompile(`^(/.+?):(\d+):(?:(\d+):)? (fatal error|error|warning): (.*)$`)
+	// CompileSwift normal arm64 /path/to/File.swift (in target 'App' from project 'App')
+	targetPattern = regexp.MustCompile(`\(in target '([^']+)' from project '([^']+)'\)\s*$`)
+	// === BUILD TARGET App OF PROJECT App WITH CONFIGURATION Debug ===
+	legacyTargetPattern = regexp.MustCompile(`^=== BUILD TARGET (.+) OF PROJECT (.+) WITH `)
+	// PhaseScriptExecution [CP]\ Check\ Pods\ Manifest.lock /path/to/Script-1234.sh (in target 'App' from project 'App')
+	scriptPhasePattern = regexp.MustCompile(`^PhaseScriptExecution ((?:\\ |\S)+) `)
+	// Undefined symbols for architecture arm64:
+	undefinedSymbolsPattern = regexp.MustCompile(`^Undefined symbols for architecture (\S+):`)
+	//   "_OBJC_CLASS_$_Foo", referenced from:
+	undefinedSymbolPattern = regexp.MustCompile(`^\s+"(.+)", referenced from:`)
+	// ld: library not found for -lPods-App
+	linkerPattern = regexp.MustCompile(`^ld: (?:(warning|error): )?(.*)$`)
+	// xcodebuild: error: Unable to find a destination matching the provided destination specifier
+	genericPattern = regexp.MustCompile(`^(?:xcodebuild: |clang: |swift-frontend: )?(error|warning): (.*)$`)
+	// (3 failures)
+	failureCountPattern = regexp.MustCompile(`^\(\d+ failures?\)$`)
+)
+
+// Parser collects issues from xcodebuild's raw output line by line.
+type Parser struct {
+	report Report
+	seen   map[string]bool
+
+	target      string
+	project     string
+	scriptPhase string
+
+	undefinedSymbolsArch string
+	undefinedSymbols     []string
+
+	inFailedCommands bool
+}
+
+// NewParser ...
+func NewParser() *Parser {
+	return &Parser{seen: map[string]bool{}}
+}
+
+// ParseLine processes a single line of the raw xcodebuild log.
+func (p *Parser) ParseLine(line string) {
+	line = strings.TrimRight(line, "\r")
+
+	if p.undefinedSymbolsArch != "" {
+		if match := undefinedSymbolPattern.FindStringSubmatch(line); match != nil {
+			p.undefinedSymbols = append(p.undefinedSymbols, match[1])
+			return
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			return
+		}
+		p.flushUndefinedSymbols()
+	}
+
+	if p.inFailedCommands {
+		trimmed := strings.TrimSpace(line)
+		if failureCountPattern.MatchString(trimmed) || trimmed == "" {
+			p.inFailedCommands = false
+		} else {
+			p.report.FailedCommands = append(p.report.FailedCommands, trimmed)
+		}
+		return
+	}
+
+	if line == "The following build commands failed:" {
+		p.inFailedCommands = true
+		return
+	}
+
+	if match := targetPattern.FindStringSubmatch(line); match != nil {
+		p.target, p.project = match[1], match[2]
+	} else if match := legacyTargetPattern.FindStringSubmatch(line); match != nil {
+		p.target, p.project = match[1], match[2]
+	}
+
+	if match := scriptPhasePattern.FindStringSubmatch(line); match != nil {
+		p.scriptPhase = strings.ReplaceAll(match[1], `\ `, " ")
+		return
+	}
+
+	if strings.HasPrefix(line, "Command PhaseScriptExecution failed") {
+		message := line
+		if p.scriptPhase != "" {
+			message = "Run script build phase '" + p.scriptPhase + "' failed with a nonzero exit code"
+		}
+		p.add(Issue{Kind: KindScriptPhase, Severity: SeverityError, Message: message})
+		return
+	}
+
+	if match := diagnosticPattern.FindStringSubmatch(line); match != nil {
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		p.add(Issue{
+			Kind:     KindCompiler,
+			Severity: severity(match[4]),
+			File:     match[1],
+			Line:     lineNumber,
+			Column:   column,
+			Message:  match[5],
+		})
+		return
+	}
+
+	if match := undefinedSymbolsPattern.FindStringSubmatch(line); match != nil {
+		p.undefinedSymbolsArch = match[1]
+		return
+	}
+
+	if match := linkerPattern.FindStringSubmatch(line); match != nil {
+		p.add(Issue{Kind: KindLinker, Severity: severity(match[1]), Message: match[2]})
+		return
+	}
+
+	if strings.HasPrefix(line, "clang: error: linker command failed") {
+		p.add(Issue{Kind: KindLinker, Severity: SeverityError, Message: strings.TrimPrefix(line, "clang: error: ")})
+		return
+	}
+
+	if match := genericPattern.FindStringSubmatch(line); match != nil {
+		p.add(Issue{Kind: KindBuild, Severity: severity(match[1]), Message: match[2]})
+	}
+}
+
+// Report returns the issues collected so far.
+func (p *Parser) Report() Report {
+	p.flushUndefinedSymbols()
+	return p.report
+}
+
+func (p *Parser) flushUndefinedSymbols() {
+	if p.undefinedSymbolsArch == "" {
+		return
+	}
+
+	message := "Undefined symbols for architecture " + p.undefinedSymbolsArch
+	if len(p.undefinedSymbols) > 0 {
+		message += ": " + strings.Join(p.undefinedSymbols, ", ")
+	}
+	p.add(Issue{Kind: KindLinker, Severity: SeverityError, Message: message})
+
+	p.undefinedSymbolsArch = ""
+	p.undefinedSymbols = nil
+}
+
+func (p *Parser) add(issue Issue) {
+	issue.Target = p.target
+	issue.Project = p.project
+
+	key := issue.key()
+	if p.seen[key] {
+		return
+	}
+	p.seen[key] = true
+
+	if issue.Severity == SeverityError {
+		p.report.Errors = append(p.report.Errors, issue)
+	} else {
+		p.report.Warnings = append(p.report.Warnings, issue)
+	}
+}
+
+func severity(s string) Severity {
+	if s == "warning" {
+		return SeverityWarning
+	}
+	return SeverityError
+}
@@ -0,0 +1,202 @@
+package buildlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseLines(lines ...string) Report {
+	p := NewParser()
+	for _, line := range lines {
+		p.ParseLine(line)
+	}
+	return p.Report()
+}
+
+func TestParser(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		wantErrors   []Issue
+		wantWarnings []Issue
+		wantFailed   []string
+	}{
+		{
+			name: "compiler error with target",
+			lines: []string{
+				"CompileSwift normal arm64 /src/App/View.swift (in target 'App' from project 'App')",
+				"/src/App/View.swift:12:5: error: cannot find 'foo' in scope",
+			},
+			wantErrors: []Issue{{Kind: KindCompiler, Severity: SeverityError, File: "/src/App/View.swift", Line: 12, Column: 5, Message: "cannot find 'foo' in scope", Target: "App", Project: "App"}},
+		},
+		{
+			name: "compiler warning without column",
+			lines: []string{
+				"=== BUILD TARGET Lib OF PROJECT Pods WITH CONFIGURATION Debug ===",
+				"/src/Lib/Lib.m:3: warning: unused variable 'x'",
+			},
+			wantWarnings: []Issue{{Kind: KindCompiler, Severity: SeverityWarning, File: "/src/Lib/Lib.m", Line: 3, Message: "unused variable 'x'", Target: "Lib", Project: "Pods"}},
+		},
+		{
+			name: "fatal error is an error",
+			lines: []string{
+				"/src/App/Bridging.h:1:9: fatal error: 'Foo.h' file not found",
+			},
+			wantErrors: []Issue{{Kind: KindCompiler, Severity: SeverityError, File: "/src/App/Bridging.h", Line: 1, Column: 9, Message: "'Foo.h' file not found"}},
+		},
+		{
+			name: "duplicate diagnostics are reported once",
+			lines: []string{
+				"/src/App/View.swift:12:5: error: cannot find 'foo' in scope",
+				"/src/App/View.swift:12:5: error: cannot find 'foo' in scope",
+			},
+			wantErrors: []Issue{{Kind: KindCompiler, Severity: SeverityError, File: "/src/App/View.swift", Line: 12, Column: 5, Message: "cannot find 'foo' in scope"}},
+		},
+		{
+			name: "undefined symbols are merged into one linker error",
+			lines: []string{
+				"Undefined symbols for architecture arm64:",
+				`  "_OBJC_CLASS_$_Foo", referenced from:`,
+				"      objc-class-ref in View.o",
+				`  "_bar", referenced from:`,
+				"      _main in main.o",
+				"ld: symbol(s) not found for architecture arm64",
+			},
+			wantErrors: []Issue{
+				{Kind: KindLinker, Severity: SeverityError, Message: "Undefined symbols for architecture arm64: _OBJC_CLASS_$_Foo, _bar"},
+				{Kind: KindLinker, Severity: SeverityError, Message: "symbol(s) not found for architecture arm64"},
+			},
+		},
+		{
+			name: "undefined symbols at the end of the log",
+			lines: []string{
+				"Undefined symbols for architecture x86_64:",
+			},
+			wantErrors: []Issue{{Kind: KindLinker, Severity: SeverityError, Message: "Undefined symbols for architecture x86_64"}},
+		},
+		{
+			name: "linker warning",
+			lines: []string{
+				"ld: warning: directory not found for option '-F/missing'",
+			},
+			wantWarnings: []Issue{{Kind: KindLinker, Severity: SeverityWarning, Message: "directory not found for option '-F/missing'"}},
+		},
+		{
+			name: "linker command failed",
+			lines: []string{
+				"clang: error: linker command failed with exit code 1 (use -v to see invocation)",
+			},
+			wantErrors: []Issue{{Kind: KindLinker, Severity: SeverityError, Message: "linker command failed with exit code 1 (use -v to see invocation)"}},
+		},
+		{
+			name: "script phase failure names the phase",
+			lines: []string{
+				`PhaseScriptExecution [CP]\ Check\ Pods\ Manifest.lock /tmp/Script-1.sh (in target 'App' from project 'App')`,
+				"Command PhaseScriptExecution failed with a nonzero exit code",
+			},
+			wantErrors: []Issue{{Kind: KindScriptPhase, Severity: SeverityError, Message: "Run script build phase '[CP] Check Pods Manifest.lock' failed with a nonzero exit code", Target: "App", Project: "App"}},
+		},
+		{
+			name: "generic xcodebuild error",
+			lines: []string{
+				"xcodebuild: error: Unable to find a destination matching the provided destination specifier",
+			},
+			wantErrors: []Issue{{Kind: KindBuild, Severity: SeverityError, Message: "Unable to find a destination matching the provided destination specifier"}},
+		},
+		{
+			name: "failed commands",
+			lines: []string{
+				"The following build commands failed:",
+				"\tCompileSwift normal arm64 /src/App/View.swift (in target 'App' from project 'App')",
+				"\tPhaseScriptExecution Lint /tmp/Script-2.sh (in target 'App' from project 'App')",
+				"(2 failures)",
+				"error: after the list",
+			},
+			wantErrors: []Issue{{Kind: KindBuild, Severity: SeverityError, Message: "after the list"}},
+			wantFailed: []string{
+				"CompileSwift normal arm64 /src/App/View.swift (in target 'App' from project 'App')",
+				"PhaseScriptExecution Lint /tmp/Script-2.sh (in target 'App' from project 'App')",
+			},
+		},
+		{
+			name: "carriage returns are trimmed",
+			lines: []string{
+				"warning: no rule to process file\r",
+			},
+			wantWarnings: []Issue{{Kind: KindBuild, Severity: SeverityWarning, Message: "no rule to process file"}},
+		},
+		{
+			name: "unrelated lines",
+			lines: []string{
+				"Build settings from command line:",
+				"    SDKROOT = iphonesimulator",
+				"** BUILD SUCCEEDED **",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseLines(tt.lines...)
+			if !reflect.DeepEqual(got.Errors, tt.wantErrors) {
+				t.Errorf("errors = %+v, want %+v", got.Errors, tt.wantErrors)
+			}
+			if !reflect.DeepEqual(got.Warnings, tt.wantWarnings) {
+				t.Errorf("warnings = %+v, want %+v", got.Warnings, tt.wantWarnings)
+			}
+			if !reflect.DeepEqual(got.FailedCommands, tt.wantFailed) {
+				t.Errorf("failed commands = %q, want %q", got.FailedCommands, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestIssueString(t *testing.T) {
+	tests := []struct {
+		name  string
+		issue Issue
+		want  string
+	}{
+		{
+			name:  "full location",
+			issue: Issue{Severity: SeverityError, File: "/src/a.swift", Line: 1, Column: 2, Message: "boom", Target: "App"},
+			want:  "/src/a.swift:1:2: error: boom (in target 'App')",
+		},
+		{
+			name:  "file and line",
+			issue: Issue{Severity: SeverityWarning, File: "/src/a.m", Line: 3, Message: "careful"},
+			want:  "/src/a.m:3: warning: careful",
+		},
+		{
+			name:  "no location",
+			issue: Issue{Severity: SeverityError, Message: "boom"},
+			want:  "error: boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.issue.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportFirstErrors(t *testing.T) {
+	report := parseLines(
+		"error: one",
+		"error: two",
+		"error: three",
+	)
+
+	var messages []string
+	for _, issue := range report.FirstErrors(2) {
+		messages = append(messages, issue.Message)
+	}
+	if got := strings.Join(messages, ","); got != "one,two" {
+		t.Errorf("FirstErrors(2) = %s, want one,two", got)
+	}
+	if got := len(report.FirstErrors(10)); got != 3 {
+		t.Errorf("len(FirstErrors(10)) = %d, want 3", got)
+	}
+}
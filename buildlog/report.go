@@ -0,0 +1,38 @@
+package buildlog
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Report is the machine-readable summary of an xcodebuild log.
+type Report struct {
+	Errors         []Issue  `json:"errors"`
+	Warnings       []Issue  `json:"warnings"`
+	FailedCommands []string `json:"failed_commands,omitempty"`
+}
+
+// FirstErrors returns at most n errors in the order they appeared in the log.
+func (r Report) FirstErrors(n int) []Issue {
+	if len(r.Errors) <= n {
+		return r.Errors
+	}
+	return r.Errors[:n]
+}
+
+// WriteJSON writes the report to the given path.
+func (r Report) WriteJSON(pth string) error {
+	if r.Errors == nil {
+		r.Errors = []Issue{}
+	}
+	if r.Warnings == nil {
+		r.Warnings = []Issue{}
+	}
+
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(pth, content, 0644)
+}
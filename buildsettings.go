@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-steputils/tools"
+	"github.com/bitrise-io/go-utils/errorutil"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-xcode/xcodebuild"
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+)
+
+const (
+	bitriseBuildSettingsPathEnvKey  = "BITRISE_BUILD_SETTINGS_PATH"
+	bitriseBuildSettingEnvKeyPrefix = "BITRISE_BUILD_SETTING_"
+
+	applicationProductType = "com.apple.product-type.application"
+)
+
+// exportedBuildSettings are exported as BITRISE_BUILD_SETTING_<key> step outputs.
+var exportedBuildSettings = []string{
+	"PRODUCT_BUNDLE_IDENTIFIER",
+	"MARKETING_VERSION",
+	"CURRENT_PROJECT_VERSION",
+	"PRODUCT_NAME",
+	"SDKROOT",
+	"TARGET_BUILD_DIR",
+}
+
+// schemeProductPath returns the path of the app built by the scheme's application target.
+func schemeProductPath(settings serialized.Object) (string, error) {
+	buildDir, err := settings.String("CONFIGURATION_BUILD_DIR")
+	if err != nil {
+		return "", err
+	}
+	productName, err := settings.String("FULL_PRODUCT_NAME")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(buildDir, productName), nil
+}
+
+// buildSettingsProvider reads the build settings on first use and caches them,
+// as `xcodebuild -showBuildSettings` can take a while on large projects.
+type buildSettingsProvider struct {
+	projectPath  string
+	cfg          RunOpts
+	xcconfigPath string
+
+	settings serialized.Object
+}
+
+func newBuildSettingsProvider(projectPath string, cfg RunOpts, xcconfigPath string) *buildSettingsProvider {
+	return &buildSettingsProvider{
+		projectPath:  projectPath,
+		cfg:          cfg,
+		xcconfigPath: xcconfigPath,
+	}
+}
+
+func (p *buildSettingsProvider) get() (serialized.Object, error) {
+	if p.settings != nil {
+		return p.settings, nil
+	}
+
+	settings, err := readBuildSettings(p.projectPath, p.cfg, p.xcconfigPath)
+	if err != nil {
+		return nil, err
+	}
+	p.settings = settings
+
+	return settings, nil
+}
+
+// readBuildSettings runs `xcodebuild -showBuildSettings` with the same project, scheme,
+// configuration, destination and additional options as the build itself,
+// and returns the settings of the scheme's application target.
+func readBuildSettings(projectPath string, cfg RunOpts, xcconfigPath string) (serialized.Object, error) {
+	cmd := xcodebuild.NewShowBuildSettingsCommand(projectPath)
+	cmd.SetScheme(cfg.Scheme)
+	if cfg.Configuration != "" {
+		cmd.SetConfiguration(cfg.Configuration)
+	}
+
+	options := []string{"-destination", cfg.Destination}
+	if xcconfigPath != "" {
+		options = append(options, "-xcconfig", xcconfigPath)
+	}
+	options = append(options, cfg.XcodebuildAdditionalOptions...)
+	options = append(options, "-json")
+	cmd.SetCustomOptions(options)
+
+	log.Printf("$ %s", cmd.PrintableCmd())
+	out, err := cmd.Command().RunAndReturnTrimmedOutput()
+	if err != nil {
+		if errorutil.IsExitStatusError(err) {
+			return nil, fmt.Errorf("%s command failed, output: %s", cmd.PrintableCmd(), out)
+		}
+		return nil, fmt.Errorf("failed to run command %s: %s", cmd.PrintableCmd(), err)
+	}
+
+	target, settings, err := selectTargetBuildSettings([]byte(out), cfg.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Using the build settings of target %s", target)
+
+	return settings, nil
+}
+
+// targetBuildSettings is an entry of `xcodebuild -showBuildSettings -json`'s output.
+type targetBuildSettings struct {
+	Target        string            `json:"target"`
+	BuildSettings serialized.Object `json:"buildSettings"`
+}
+
+// selectTargetBuildSettings picks the target the scheme builds its app from,
+// as `xcodebuild -showBuildSettings` lists every target the scheme builds (dependencies, extensions, watch apps).
+// The iOS application target named after the scheme is preferred, then the first iOS application target,
+// then the first target (e.g. for schemes building only frameworks or tests).
+func selectTargetBuildSettings(content []byte, scheme string) (string, serialized.Object, error) {
+	var targets []targetBuildSettings
+	if err := json.Unmarshal(content, &targets); err != nil {
+		return "", nil, fmt.Errorf("failed to parse build settings: %s", err)
+	}
+	if len(targets) == 0 {
+		return "", nil, fmt.Errorf("no build settings found")
+	}
+
+	var applications []targetBuildSettings
+	for _, target := range targets {
+		if productType, _ := target.BuildSettings.String("PRODUCT_TYPE"); productType == applicationProductType {
+			applications = append(applications, target)
+		}
+	}
+
+	for _, target := range applications {
+		if target.Target == scheme {
+			return target.Target, target.BuildSettings, nil
+		}
+	}
+	if len(applications) > 0 {
+		return applications[0].Target, applications[0].BuildSettings, nil
+	}
+
+	return targets[0].Target, targets[0].BuildSettings, nil
+}
+
+// exportBuildSettings writes the full build settings dump as JSON and exports the key settings.
+func exportBuildSettings(settings serialized.Object, jsonPth string) error {
+	content, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize build settings: %s", err)
+	}
+	if err := os.WriteFile(jsonPth, content, 0644); err != nil {
+		return fmt.Errorf("failed to write build settings (%s): %s", jsonPth, err)
+	}
+	if err := tools.ExportEnvironmentWithEnvman(bitriseBuildSettingsPathEnvKey, jsonPth); err != nil {
+		return fmt.Errorf("failed to export %s: %s", bitriseBuildSettingsPathEnvKey, err)
+	}
+	log.Donef("%s -> %s", bitriseBuildSettingsPathEnvKey, jsonPth)
+
+	for _, key := range exportedBuildSettings {
+		value, err := settings.String(key)
+		if err != nil {
+			log.Warnf("Build setting %s not found", key)
+			continue
+		}
+
+		envKey := bitriseBuildSettingEnvKeyPrefix + key
+		if err := tools.ExportEnvironmentWithEnvman(envKey, value); err != nil {
+			return fmt.Errorf("failed to export %s: %s", envKey, err)
+		}
+		log.Donef("%s -> %s", envKey, value)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+)
+
+func TestSelectTargetBuildSettings(t *testing.T) {
+	const (
+		framework = `{"target": "Core", "action": "build", "buildSettings": {"PRODUCT_TYPE": "com.apple.product-type.framework", "PRODUCT_NAME": "Core"}}`
+		app       = `{"target": "App", "action": "build", "buildSettings": {"PRODUCT_TYPE": "com.apple.product-type.application", "PRODUCT_NAME": "App"}}`
+		otherApp  = `{"target": "Other", "action": "build", "buildSettings": {"PRODUCT_TYPE": "com.apple.product-type.application", "PRODUCT_NAME": "Other"}}`
+		watchApp  = `{"target": "Watch", "action": "build", "buildSettings": {"PRODUCT_TYPE": "com.apple.product-type.application.watchapp2", "PRODUCT_NAME": "Watch"}}`
+		extension = `{"target": "Widget", "action": "build", "buildSettings": {"PRODUCT_TYPE": "com.apple.product-type.app-extension", "PRODUCT_NAME": "Widget"}}`
+	)
+
+	tests := []struct {
+		name       string
+		content    string
+		scheme     string
+		wantTarget string
+		wantErr    bool
+	}{
+		{
+			name:       "application target after its dependencies",
+			content:    "[" + framework + "," + extension + "," + watchApp + "," + app + "]",
+			scheme:     "Scheme",
+			wantTarget: "App",
+		},
+		{
+			name:       "application target named after the scheme",
+			content:    "[" + app + "," + otherApp + "]",
+			scheme:     "Other",
+			wantTarget: "Other",
+		},
+		{
+			name:       "first application target",
+			content:    "[" + otherApp + "," + app + "]",
+			scheme:     "Scheme",
+			wantTarget: "Other",
+		},
+		{
+			name:       "no application target",
+			content:    "[" + framework + "," + extension + "]",
+			scheme:     "Core",
+			wantTarget: "Core",
+		},
+		{
+			name:    "no targets",
+			content: "[]",
+			wantErr: true,
+		},
+		{
+			name:    "invalid output",
+			content: "Build settings for action build and target App:",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, settings, err := selectTargetBuildSettings([]byte(tt.content), tt.scheme)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectTargetBuildSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if target != tt.wantTarget {
+				t.Errorf("target = %s, want %s", target, tt.wantTarget)
+			}
+			if productName, _ := settings.String("PRODUCT_NAME"); productName != tt.wantTarget {
+				t.Errorf("PRODUCT_NAME = %s, want %s", productName, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestSchemeProductPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings serialized.Object
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "application product",
+			settings: serialized.Object{"CONFIGURATION_BUILD_DIR": "/dd/Build/Products/Debug-iphonesimulator", "FULL_PRODUCT_NAME": "App.app"},
+			want:     "/dd/Build/Products/Debug-iphonesimulator/App.app",
+		},
+		{
+			name:     "no product name",
+			settings: serialized.Object{"CONFIGURATION_BUILD_DIR": "/dd/Build/Products/Debug-iphonesimulator"},
+			wantErr:  true,
+		},
+		{
+			name:     "no build dir",
+			settings: serialized.Object{"FULL_PRODUCT_NAME": "App.app"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := schemeProductPath(tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("schemeProductPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("schemeProductPath() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	cleanModeXcodebuild  = "xcodebuild"
+	cleanModeDerivedData = "derived_data"
+	cleanModeBuildFolder = "build_folder"
+)
+
+// cleanBuildDir removes the derived data or the scheme's intermediate build folder before the build.
+// The xcodebuild clean mode is handled by adding the `clean` action to the build command instead.
+func cleanBuildDir(mode string, settingsProvider *buildSettingsProvider, additionalOptions []string) error {
+	var pth string
+	switch mode {
+	case cleanModeDerivedData:
+		pth = derivedDataPathFromOptions(additionalOptions)
+		if pth == "" {
+			settings, err := settingsProvider.get()
+			if err != nil {
+				return fmt.Errorf("failed to read build settings: %s", err)
+			}
+			buildDir, err := settings.String("BUILD_DIR")
+			if err != nil {
+				return fmt.Errorf("failed to read build products dir: %s", err)
+			}
+			// BUILD_DIR is <derived data>/Build/Products
+			if filepath.Base(buildDir) != "Products" || filepath.Base(filepath.Dir(buildDir)) != "Build" {
+				return fmt.Errorf("unable to determine derived data path from build products dir (%s), set it explicitly with the `-derivedDataPath` xcodebuild option", buildDir)
+			}
+			pth = filepath.Dir(filepath.Dir(buildDir))
+		}
+	case cleanModeBuildFolder:
+		settings, err := settingsProvider.get()
+		if err != nil {
+			return fmt.Errorf("failed to read build settings: %s", err)
+		}
+		pth, err = settings.String("PROJECT_TEMP_DIR")
+		if err != nil {
+			return fmt.Errorf("failed to read intermediate build folder: %s", err)
+		}
+	default:
+		return nil
+	}
+
+	absPth, err := filepath.Abs(pth)
+	if err != nil {
+		return fmt.Errorf("failed to expand path (%s): %s", pth, err)
+	}
+	if absPth == filepath.Dir(absPth) {
+		return fmt.Errorf("refusing to remove root directory (%s)", absPth)
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil && homeDir != "" {
+		if rel, err := filepath.Rel(absPth, homeDir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("refusing to remove the home directory or its parent (%s)", absPth)
+		}
+	}
+
+	log.Printf("Removing %s", absPth)
+	if err := os.RemoveAll(absPth); err != nil {
+		return fmt.Errorf("failed to remove path (%s), error: %s", absPth, err)
+	}
+
+	return nil
+}
+
+// derivedDataPathFromOptions returns the value of the `-derivedDataPath value` or `-derivedDataPath=value` xcodebuild option.
+func derivedDataPathFromOptions(options []string) string {
+	for i, option := range options {
+		if option == "-derivedDataPath" && i+1 < len(options) {
+			return options[i+1]
+		}
+		if value, ok := strings.CutPrefix(option, "-derivedDataPath="); ok {
+			return value
+		}
+	}
+	return ""
+}
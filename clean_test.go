@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+)
+
+func TestDerivedDataPathFromOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []string
+		want    string
+	}{
+		{name: "no options"},
+		{name: "separate value", options: []string{"-quiet", "-derivedDataPath", "./ddata", "-jobs", "4"}, want: "./ddata"},
+		{name: "inline value", options: []string{"-derivedDataPath=/tmp/dd"}, want: "/tmp/dd"},
+		{name: "missing value", options: []string{"-derivedDataPath"}},
+		{name: "other option", options: []string{"-resultBundlePath", "./result"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := derivedDataPathFromOptions(tt.options); got != tt.want {
+				t.Errorf("derivedDataPathFromOptions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanBuildDir(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		// $DIR in the options and settings is replaced with the temp dir's path.
+		options  []string
+		settings map[string]string
+		// removed and kept are relative to the temp dir.
+		removed string
+		kept    []string
+		wantErr bool
+	}{
+		{
+			name:    "derived data from the option",
+			mode:    cleanModeDerivedData,
+			options: []string{"-derivedDataPath", "$DIR/DerivedData"},
+			removed: "DerivedData",
+			kept:    []string{"Project"},
+		},
+		{
+			name:    "derived data from the inline option",
+			mode:    cleanModeDerivedData,
+			options: []string{"-derivedDataPath=$DIR/DerivedData"},
+			removed: "DerivedData",
+			kept:    []string{"Project"},
+		},
+		{
+			name:     "derived data from the build dir",
+			mode:     cleanModeDerivedData,
+			settings: map[string]string{"BUILD_DIR": "$DIR/DerivedData/Build/Products"},
+			removed:  "DerivedData",
+			kept:     []string{"Project"},
+		},
+		{
+			name:     "build dir outside of derived data",
+			mode:     cleanModeDerivedData,
+			settings: map[string]string{"BUILD_DIR": "$DIR/Project/build"},
+			kept:     []string{"DerivedData", "Project"},
+			wantErr:  true,
+		},
+		{
+			name:     "build folder",
+			mode:     cleanModeBuildFolder,
+			settings: map[string]string{"PROJECT_TEMP_DIR": "$DIR/DerivedData/Build/Intermediates.noindex/App.build"},
+			removed:  "DerivedData/Build/Intermediates.noindex/App.build",
+			kept:     []string{"DerivedData/Build/Products", "Project"},
+		},
+		{
+			name:    "build folder without build settings",
+			mode:    cleanModeBuildFolder,
+			kept:    []string{"DerivedData", "Project"},
+			wantErr: true,
+		},
+		{
+			name:     "xcodebuild mode removes nothing",
+			mode:     cleanModeXcodebuild,
+			settings: map[string]string{"BUILD_DIR": "$DIR/DerivedData/Build/Products"},
+			kept:     []string{"DerivedData", "Project"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, rel := range []string{"DerivedData/Build/Products/Debug-iphonesimulator/App.app", "DerivedData/Build/Intermediates.noindex/App.build", "Project"} {
+				if err := os.MkdirAll(filepath.Join(dir, rel), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var options []string
+			for _, option := range tt.options {
+				options = append(options, strings.ReplaceAll(option, "$DIR", dir))
+			}
+			settings := serialized.Object{}
+			for key, value := range tt.settings {
+				settings[key] = strings.ReplaceAll(value, "$DIR", dir)
+			}
+
+			err := cleanBuildDir(tt.mode, &buildSettingsProvider{settings: settings}, options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cleanBuildDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.removed != "" {
+				if _, err := os.Stat(filepath.Join(dir, tt.removed)); !os.IsNotExist(err) {
+					t.Errorf("%s exists, want it removed", tt.removed)
+				}
+			}
+			for _, rel := range tt.kept {
+				if _, err := os.Stat(filepath.Join(dir, rel)); err != nil {
+					t.Errorf("%s is removed, want it kept", rel)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanBuildDirRefusesProtectedDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	derivedData := filepath.Join(home, "Library", "Developer", "Xcode", "DerivedData")
+	if err := os.MkdirAll(derivedData, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		options []string
+	}{
+		{name: "root", options: []string{"-derivedDataPath", "/"}},
+		{name: "home", options: []string{"-derivedDataPath", home}},
+		{name: "parent of home", options: []string{"-derivedDataPath=" + filepath.Dir(home)}},
+		{name: "home with a trailing separator", options: []string{"-derivedDataPath", home + string(filepath.Separator)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := cleanBuildDir(cleanModeDerivedData, &buildSettingsProvider{}, tt.options); err == nil {
+				t.Errorf("cleanBuildDir() error = nil, want an error")
+			}
+			if _, err := os.Stat(derivedData); err != nil {
+				t.Errorf("the home directory's content is removed: %s", err)
+			}
+		})
+	}
+
+	if err := cleanBuildDir(cleanModeDerivedData, &buildSettingsProvider{}, []string{"-derivedDataPath", derivedData}); err != nil {
+		t.Fatalf("cleanBuildDir() error = %v for a dir under home", err)
+	}
+	if _, err := os.Stat(derivedData); !os.IsNotExist(err) {
+		t.Errorf("%s exists, want it removed", derivedData)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/util"
+)
+
+const (
+	dSYMsDirName = "dSYMs"
+	// dSYMsNameSuffix names the exported dSYMs dir after the scheme and configuration, for example App-Debug-dSYMs.
+	dSYMsNameSuffix = "-dSYMs"
+)
+
+// codeBundleExtensions are the bundles which have an executable, so the build may generate a dSYM for them.
+var codeBundleExtensions = []string{".app", ".appex", ".framework", ".xpc"}
+
+// findDSYMs returns the debug symbol bundles of the build.
+// Archives collect them into their dSYMs dir. Build and build-for-testing leave them in the products dir,
+// which may hold the dSYMs of other schemes too, so only the dSYMs of the scheme's product
+// (productPth) and the bundles in it are returned, matched by name (App.app.dSYM for App.app).
+func findDSYMs(archivePth, productsDir, productPth string) ([]string, error) {
+	if archivePth != "" {
+		sourceDir := filepath.Join(archivePth, dSYMsDirName)
+		pths, err := filepath.Glob(filepath.Join(sourceDir, "*.dSYM"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to search for dSYMs in %s: %s", sourceDir, err)
+		}
+		return pths, nil
+	}
+
+	names, err := codeBundleNames(productPth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the bundles of %s: %s", productPth, err)
+	}
+
+	pths, err := filepath.Glob(filepath.Join(productsDir, "*.dSYM"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for dSYMs in %s: %s", productsDir, err)
+	}
+
+	var dsyms []string
+	for _, pth := range pths {
+		if names[strings.TrimSuffix(filepath.Base(pth), ".dSYM")] {
+			dsyms = append(dsyms, pth)
+		} else {
+			log.Debugf("Skipping %s, it doesn't belong to the scheme's product", pth)
+		}
+	}
+	return dsyms, nil
+}
+
+// codeBundleNames returns the names of the bundle at pth and of the code bundles in it.
+func codeBundleNames(pth string) (map[string]bool, error) {
+	names := map[string]bool{}
+	err := filepath.WalkDir(pth, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && slices.Contains(codeBundleExtensions, filepath.Ext(d.Name())) {
+			names[d.Name()] = true
+		}
+		return nil
+	})
+	return names, err
+}
+
+// exportDSYMs copies the dSYMs into dsymDir and zips that dir next to it.
+// It returns empty paths if the build didn't generate any dSYM.
+func exportDSYMs(dsyms []string, dsymDir string) (string, string, error) {
+	if len(dsyms) == 0 {
+		return "", "", nil
+	}
+
+	if err := os.MkdirAll(dsymDir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create dSYMs dir: %s", err)
+	}
+
+	for _, dsym := range dsyms {
+		if err := util.CopyDir(dsym, filepath.Join(dsymDir, filepath.Base(dsym))); err != nil {
+			return "", "", fmt.Errorf("failed to copy %s: %s", dsym, err)
+		}
+		log.Donef("Copy: $BITRISE_DEPLOY_DIR/%s/%s", filepath.Base(dsymDir), filepath.Base(dsym))
+	}
+
+	zipPth := dsymDir + ".zip"
+	if err := util.ZipDir(dsymDir, zipPth); err != nil {
+		return "", "", fmt.Errorf("failed to zip dSYMs: %s", err)
+	}
+	log.Donef("Package: $BITRISE_DEPLOY_DIR/%s", filepath.Base(zipPth))
+
+	return dsymDir, zipPth, nil
+}
+
+// exportXCArchive zips the archive into the output dir.
+func exportXCArchive(archivePth, zipPth string) error {
+	if err := util.ZipDir(archivePth, zipPth); err != nil {
+		return fmt.Errorf("failed to zip %s: %s", archivePth, err)
+	}
+	log.Donef("Package: $BITRISE_DEPLOY_DIR/%s", filepath.Base(zipPth))
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFindDSYMs(t *testing.T) {
+	dir := t.TempDir()
+	productsDir := filepath.Join(dir, "Build", "Products", "Debug-iphonesimulator")
+	for _, rel := range []string{
+		"App.app/Watch/Watch.app",
+		"App.app/PlugIns/Widget.appex",
+		"App.app/Frameworks/Core.framework",
+		"App.app.dSYM",
+		"Watch.app.dSYM",
+		"Widget.appex.dSYM",
+		"Core.framework.dSYM",
+		"Other.app",
+		"Other.app.dSYM",
+		"Stale.framework.dSYM",
+	} {
+		if err := os.MkdirAll(filepath.Join(productsDir, rel), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive := filepath.Join(dir, "App.xcarchive")
+	for _, rel := range []string{"dSYMs/App.app.dSYM", "dSYMs/Core.framework.dSYM", "Products/Applications/App.app"} {
+		if err := os.MkdirAll(filepath.Join(archive, rel), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		archivePth string
+		productPth string
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name:       "archive",
+			archivePth: archive,
+			want:       []string{"App.app.dSYM", "Core.framework.dSYM"},
+		},
+		{
+			name:       "products of the scheme",
+			productPth: filepath.Join(productsDir, "App.app"),
+			want:       []string{"App.app.dSYM", "Core.framework.dSYM", "Watch.app.dSYM", "Widget.appex.dSYM"},
+		},
+		{
+			name:       "products of another scheme",
+			productPth: filepath.Join(productsDir, "Other.app"),
+			want:       []string{"Other.app.dSYM"},
+		},
+		{
+			name:       "missing product",
+			productPth: filepath.Join(productsDir, "Missing.app"),
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pths, err := findDSYMs(tt.archivePth, productsDir, tt.productPth)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findDSYMs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, pth := range pths {
+				got = append(got, filepath.Base(pth))
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findDSYMs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportDSYMs(t *testing.T) {
+	tests := []struct {
+		name  string
+		dsyms []string
+	}{
+		{name: "no dSYMs"},
+		{name: "dSYMs", dsyms: []string{"App.app.dSYM", "Core.framework.dSYM"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srcDir := t.TempDir()
+			var dsyms []string
+			for _, name := range tt.dsyms {
+				dwarf := filepath.Join(srcDir, name, "Contents", "Resources", "DWARF", name)
+				if err := os.MkdirAll(filepath.Dir(dwarf), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(dwarf, []byte(name), 0644); err != nil {
+					t.Fatal(err)
+				}
+				dsyms = append(dsyms, filepath.Join(srcDir, name))
+			}
+
+			outputDir := t.TempDir()
+			dsymDir, zipPth, err := exportDSYMs(dsyms, filepath.Join(outputDir, "App"+dSYMsNameSuffix))
+			if err != nil {
+				t.Fatalf("exportDSYMs() error = %v", err)
+			}
+
+			if len(tt.dsyms) == 0 {
+				if dsymDir != "" || zipPth != "" {
+					t.Errorf("exportDSYMs() = %s, %s, want no outputs", dsymDir, zipPth)
+				}
+				if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+					t.Errorf("output dir = %v, want it empty", entries)
+				}
+				return
+			}
+
+			if want := filepath.Join(outputDir, "App-dSYMs"); dsymDir != want || zipPth != want+".zip" {
+				t.Errorf("exportDSYMs() = %s, %s, want %s and its zip", dsymDir, zipPth, want)
+			}
+			files := zipFiles(t, zipPth)
+			for _, name := range tt.dsyms {
+				if _, err := os.Stat(filepath.Join(dsymDir, name, "Contents", "Resources", "DWARF", name)); err != nil {
+					t.Errorf("%s is not copied: %s", name, err)
+				}
+				if want := filepath.ToSlash(filepath.Join("App-dSYMs", name, "Contents", "Resources", "DWARF", name)); !files[want] {
+					t.Errorf("zip = %v, want it to contain %s", files, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExportXCArchive(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "App-simulator.xcarchive")
+	writeTestBundle(t, filepath.Join(archive, "Products", "Applications", "App.app"), map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App"})
+
+	zipPth := filepath.Join(t.TempDir(), "App-simulator.xcarchive.zip")
+	if err := exportXCArchive(archive, zipPth); err != nil {
+		t.Fatalf("exportXCArchive() error = %v", err)
+	}
+
+	want := "App-simulator.xcarchive/Products/Applications/App.app/Info.plist"
+	if files := zipFiles(t, zipPth); !files[want] {
+		t.Errorf("zip = %v, want it to contain %s", files, want)
+	}
+
+	if err := exportXCArchive(filepath.Join(t.TempDir(), "Missing.xcarchive"), zipPth+"2"); err == nil {
+		t.Errorf("exportXCArchive() error = nil for a missing archive, want an error")
+	}
+}
+
+// zipFiles returns the names of the regular files in the zip.
+func zipFiles(t *testing.T, pth string) map[string]bool {
+	t.Helper()
+
+	reader, err := zip.OpenReader(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	files := map[string]bool{}
+	for _, file := range reader.File {
+		if !file.FileInfo().IsDir() {
+			files[file.Name] = true
+		}
+	}
+	return files
+}
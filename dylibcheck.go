@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/machoinfo"
+)
+
+// systemLibraryPrefixes are the install name prefixes of the libraries provided by the simulator runtime.
+var systemLibraryPrefixes = []string{
+	"/usr/lib/",
+	"/System/Library/",
+	"/System/iOSSupport/",
+	"/Developer/",
+}
+
+// checkDylibs resolves the libraries every binary of the exported bundles links against,
+// and reports the ones dyld wouldn't find: neither provided by the system nor embedded in the bundle.
+func checkDylibs(artifacts []Artifact, mode string) error {
+	var problems []string
+	for _, artifact := range artifacts {
+		bundleProblems, err := bundleDylibProblems(artifact.Path, artifact.Info.Executable)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", filepath.Base(artifact.Path), err))
+			continue
+		}
+		problems = append(problems, bundleProblems...)
+	}
+
+	if len(problems) == 0 {
+		log.Donef("All linked libraries are available")
+		return nil
+	}
+
+	for _, problem := range problems {
+		if mode == binaryCheckFail {
+			log.Errorf("- %s", problem)
+		} else {
+			log.Warnf("- %s", problem)
+		}
+	}
+	if mode == binaryCheckFail {
+		return fmt.Errorf("%d unresolved library reference(s) found", len(problems))
+	}
+	return nil
+}
+
+// bundleDylibProblems checks the binaries of an app bundle (see bundleBinaries).
+// Binaries are checked against the executable loading them, as @executable_path and its rpaths refer to that,
+// which is the extension's own executable for the binaries of an app extension.
+// executableName is the bundle's CFBundleExecutable, if it's already known.
+func bundleDylibProblems(bundlePth, executableName string) ([]string, error) {
+	binaries, err := bundleBinaries(bundlePth, executableName)
+	if err != nil {
+		return nil, err
+	}
+
+	inspected := map[string]machoinfo.Binary{}
+	inspect := func(pth string) (machoinfo.Binary, error) {
+		if binary, ok := inspected[pth]; ok {
+			return binary, nil
+		}
+		binary, err := machoinfo.Inspect(pth)
+		if err != nil {
+			return machoinfo.Binary{}, fmt.Errorf("not a valid Mach-O binary (%s): %s", pth, err)
+		}
+		inspected[pth] = binary
+		return binary, nil
+	}
+
+	var problems []string
+	for _, bundleBinary := range binaries {
+		executable, err := inspect(bundleBinary.Executable)
+		if err != nil {
+			return nil, err
+		}
+		binary, err := inspect(bundleBinary.Path)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, binaryDylibProblems(binary, executable, bundlePth)...)
+	}
+
+	return problems, nil
+}
+
+func binaryDylibProblems(binary, executable machoinfo.Binary, rootPth string) []string {
+	rel, err := filepath.Rel(filepath.Dir(rootPth), binary.Path)
+	if err != nil {
+		rel = binary.Path
+	}
+
+	loaderDir := filepath.Dir(binary.Path)
+	executableDir := filepath.Dir(executable.Path)
+
+	var problems []string
+	reported := map[string]bool{}
+	for _, slice := range binary.Slices {
+		// dyld searches the rpaths of the loading binary, then the ones of the executable.
+		// @loader_path in an rpath refers to the binary defining it.
+		var rpaths []string
+		for _, rpath := range slice.Rpaths {
+			rpaths = append(rpaths, expandLoaderPaths(rpath, loaderDir, executableDir))
+		}
+		for _, executableSlice := range executable.Slices {
+			if executableSlice.Arch != slice.Arch {
+				continue
+			}
+			for _, rpath := range executableSlice.Rpaths {
+				rpaths = append(rpaths, expandLoaderPaths(rpath, executableDir, executableDir))
+			}
+		}
+
+		for _, dylib := range slice.Dylibs {
+			if reported[dylib.Name] {
+				continue
+			}
+
+			if resolved, ok := resolveDylib(dylib.Name, loaderDir, executableDir, rpaths, rootPth); ok {
+				log.Debugf("%s: %s -> %s", rel, dylib.Name, resolved)
+				continue
+			}
+			if dylib.Weak {
+				log.Debugf("%s: weak library not found: %s", rel, dylib.Name)
+				continue
+			}
+
+			reported[dylib.Name] = true
+			problem := fmt.Sprintf("%s: library not found: %s", rel, dylib.Name)
+			if strings.HasPrefix(dylib.Name, "@rpath/") {
+				if len(rpaths) == 0 {
+					problem += " (the binary and its executable define no LC_RPATH)"
+				} else {
+					problem += fmt.Sprintf(" (searched rpaths: %s)", strings.Join(relativePaths(rpaths, filepath.Dir(rootPth)), ", "))
+				}
+			}
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// resolveDylib returns the path dyld would load the library from, if the library is available:
+// it is either provided by the system or embedded in the bundle.
+func resolveDylib(name, loaderDir, executableDir string, rpaths []string, rootPth string) (string, bool) {
+	candidates := []string{expandLoaderPaths(name, loaderDir, executableDir)}
+	if rest, ok := strings.CutPrefix(name, "@rpath/"); ok {
+		candidates = nil
+		for _, rpath := range rpaths {
+			candidates = append(candidates, filepath.Join(rpath, rest))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if isSystemLibrary(candidate) {
+			return candidate, true
+		}
+		if !strings.HasPrefix(candidate, rootPth+string(filepath.Separator)) {
+			continue
+		}
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+func expandLoaderPaths(pth, loaderDir, executableDir string) string {
+	if rest, ok := strings.CutPrefix(pth, "@loader_path"); ok {
+		return filepath.Join(loaderDir, rest)
+	}
+	if rest, ok := strings.CutPrefix(pth, "@executable_path"); ok {
+		return filepath.Join(executableDir, rest)
+	}
+	return pth
+}
+
+func isSystemLibrary(pth string) bool {
+	for _, prefix := range systemLibraryPrefixes {
+		if strings.HasPrefix(pth, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func relativePaths(pths []string, baseDir string) []string {
+	var rels []string
+	for _, pth := range pths {
+		if rel, err := filepath.Rel(baseDir, pth); err == nil && !strings.HasPrefix(rel, "..") {
+			pth = rel
+		}
+		rels = append(rels, pth)
+	}
+	return rels
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"debug/macho"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/machoinfo"
+)
+
+const (
+	testLoadCmdLoadDylib     = 0xc
+	testLoadCmdLoadWeakDylib = 0x80000018
+	testLoadCmdRpath         = 0x8000001c
+)
+
+func testDylib(cmd uint32, name string) []byte {
+	// struct dylib_command { cmd, cmdsize, name offset, timestamp, current_version, compatibility_version }
+	return testLoadCommand(cmd, []uint32{24, 0, 0x10000, 0x10000}, name)
+}
+
+func testRpath(pth string) []byte {
+	return testLoadCommand(testLoadCmdRpath, []uint32{12}, pth)
+}
+
+func TestExpandLoaderPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		pth  string
+		want string
+	}{
+		{name: "loader path", pth: "@loader_path/Frameworks", want: "/App.app/Frameworks/Lib.framework/Frameworks"},
+		{name: "executable path", pth: "@executable_path/Frameworks", want: "/App.app/Frameworks"},
+		{name: "parent of the loader", pth: "@loader_path/../../Frameworks", want: "/App.app/Frameworks"},
+		{name: "absolute path", pth: "/usr/lib/swift", want: "/usr/lib/swift"},
+		{name: "rpath is not expanded", pth: "@rpath/Lib.framework/Lib", want: "@rpath/Lib.framework/Lib"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandLoaderPaths(tt.pth, "/App.app/Frameworks/Lib.framework", "/App.app"); got != tt.want {
+				t.Errorf("expandLoaderPaths() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveDylib(t *testing.T) {
+	dir := t.TempDir()
+	appPth := filepath.Join(dir, "App.app")
+	for _, rel := range []string{
+		"App.app/App",
+		"App.app/Frameworks/Lib.framework/Lib",
+		"App.app/Frameworks/Lib.framework/Helper.dylib",
+		"Outside.framework/Outside",
+	} {
+		writeTestBinary(t, filepath.Join(dir, rel), nil)
+	}
+	loaderDir := filepath.Join(appPth, "Frameworks", "Lib.framework")
+
+	tests := []struct {
+		name   string
+		dylib  string
+		rpaths []string
+		want   string
+	}{
+		{
+			name:   "rpath resolved through the second rpath",
+			dylib:  "@rpath/Lib.framework/Lib",
+			rpaths: []string{filepath.Join(appPth, "PlugIns"), filepath.Join(appPth, "Frameworks")},
+			want:   filepath.Join(appPth, "Frameworks", "Lib.framework", "Lib"),
+		},
+		{
+			name:   "rpath not found in any rpath",
+			dylib:  "@rpath/Missing.framework/Missing",
+			rpaths: []string{filepath.Join(appPth, "Frameworks"), appPth},
+		},
+		{
+			name:  "rpath without rpaths",
+			dylib: "@rpath/Lib.framework/Lib",
+		},
+		{
+			name:  "loader path",
+			dylib: "@loader_path/Helper.dylib",
+			want:  filepath.Join(loaderDir, "Helper.dylib"),
+		},
+		{
+			name:  "executable path",
+			dylib: "@executable_path/Frameworks/Lib.framework/Lib",
+			want:  filepath.Join(appPth, "Frameworks", "Lib.framework", "Lib"),
+		},
+		{
+			name:  "system library",
+			dylib: "/usr/lib/libSystem.B.dylib",
+			want:  "/usr/lib/libSystem.B.dylib",
+		},
+		{
+			name:   "system library through an rpath",
+			dylib:  "@rpath/libswiftCore.dylib",
+			rpaths: []string{"/usr/lib/swift"},
+			want:   "/usr/lib/swift/libswiftCore.dylib",
+		},
+		{
+			name:   "library outside of the bundle",
+			dylib:  "@rpath/Outside.framework/Outside",
+			rpaths: []string{dir},
+		},
+		{
+			name:  "absolute path outside of the bundle",
+			dylib: filepath.Join(dir, "Outside.framework", "Outside"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolveDylib(tt.dylib, loaderDir, appPth, tt.rpaths, appPth)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("resolveDylib() = %s, %v, want %s", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestBinaryDylibProblems(t *testing.T) {
+	arm64 := func(cmds ...[]byte) []byte {
+		return testThinBinary(macho.CpuArm64, append([][]byte{testBuildVersion(machoinfo.PlatformIOSSimulator)}, cmds...)...)
+	}
+
+	tests := []struct {
+		name       string
+		executable []byte
+		framework  []byte
+		want       []string
+	}{
+		{
+			name:       "resolved through the rpaths of the framework",
+			executable: arm64(),
+			framework:  arm64(testRpath("@loader_path/.."), testDylib(testLoadCmdLoadDylib, "@rpath/Other.framework/Other")),
+		},
+		{
+			name:       "resolved through the rpaths of the executable",
+			executable: arm64(testRpath("/usr/lib/swift"), testRpath("@executable_path/Frameworks")),
+			framework:  arm64(testDylib(testLoadCmdLoadDylib, "@rpath/Other.framework/Other")),
+		},
+		{
+			name:       "rpaths of another architecture of the executable are not searched",
+			executable: testThinBinary(macho.CpuAmd64, testRpath("@executable_path/Frameworks")),
+			framework:  arm64(testDylib(testLoadCmdLoadDylib, "@rpath/Other.framework/Other")),
+			want:       []string{"App.app/Frameworks/Lib.framework/Lib: library not found: @rpath/Other.framework/Other (the binary and its executable define no LC_RPATH)"},
+		},
+		{
+			name:       "missing library lists the searched rpaths",
+			executable: arm64(testRpath("@executable_path/Frameworks")),
+			framework:  arm64(testDylib(testLoadCmdLoadDylib, "@rpath/Missing.framework/Missing")),
+			want:       []string{"App.app/Frameworks/Lib.framework/Lib: library not found: @rpath/Missing.framework/Missing (searched rpaths: App.app/Frameworks)"},
+		},
+		{
+			name:       "missing weak library",
+			executable: arm64(testRpath("@executable_path/Frameworks")),
+			framework:  arm64(testDylib(testLoadCmdLoadWeakDylib, "@rpath/Missing.framework/Missing")),
+		},
+		{
+			name:       "system libraries are skipped",
+			executable: arm64(),
+			framework: arm64(
+				testDylib(testLoadCmdLoadDylib, "/usr/lib/libSystem.B.dylib"),
+				testDylib(testLoadCmdLoadDylib, "/System/Library/Frameworks/UIKit.framework/UIKit"),
+			),
+		},
+		{
+			name:       "library resolving outside of the bundle",
+			executable: arm64(testRpath("@executable_path/../..")),
+			framework:  arm64(testDylib(testLoadCmdLoadDylib, "@rpath/Outside.framework/Outside")),
+			want:       []string{"App.app/Frameworks/Lib.framework/Lib: library not found: @rpath/Outside.framework/Outside (searched rpaths: %DIR%)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			appPth := filepath.Join(dir, "App.app")
+			executablePth := filepath.Join(appPth, "App")
+			frameworkPth := filepath.Join(appPth, "Frameworks", "Lib.framework", "Lib")
+			writeTestBinary(t, executablePth, tt.executable)
+			writeTestBinary(t, frameworkPth, tt.framework)
+			writeTestBinary(t, filepath.Join(appPth, "Frameworks", "Other.framework", "Other"), nil)
+			writeTestBinary(t, filepath.Join(filepath.Dir(dir), "Outside.framework", "Outside"), nil)
+
+			executable, err := machoinfo.Inspect(executablePth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			framework, err := machoinfo.Inspect(frameworkPth)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want []string
+			for _, problem := range tt.want {
+				want = append(want, strings.ReplaceAll(problem, "%DIR%", filepath.Dir(dir)))
+			}
+			if got := binaryDylibProblems(framework, executable, appPth); !reflect.DeepEqual(got, want) {
+				t.Errorf("binaryDylibProblems() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestBundleDylibProblems(t *testing.T) {
+	arm64 := func(cmds ...[]byte) []byte {
+		return testThinBinary(macho.CpuArm64, append([][]byte{testBuildVersion(machoinfo.PlatformIOSSimulator)}, cmds...)...)
+	}
+
+	appPth := filepath.Join(t.TempDir(), "App.app")
+	writeTestBundle(t, appPth, map[string]interface{}{"CFBundleExecutable": "App"})
+	// Debug builds of Xcode 16 move the code of the app into <App>.debug.dylib, loaded by a stub executable.
+	writeTestBinary(t, filepath.Join(appPth, "App"), arm64(
+		testRpath("@executable_path"),
+		testRpath("@executable_path/Frameworks"),
+		testDylib(testLoadCmdLoadDylib, "@rpath/App.debug.dylib"),
+	))
+	writeTestBinary(t, filepath.Join(appPth, "App.debug.dylib"), arm64(
+		testDylib(testLoadCmdLoadDylib, "@rpath/Lib.framework/Lib"),
+		testDylib(testLoadCmdLoadDylib, "@rpath/Missing.framework/Missing"),
+	))
+	writeTestBinary(t, filepath.Join(appPth, "Frameworks", "Lib.framework", "Lib"), arm64(
+		testDylib(testLoadCmdLoadDylib, "/usr/lib/libSystem.B.dylib"),
+	))
+
+	extensionPth := filepath.Join(appPth, "PlugIns", "Widget.appex")
+	writeTestBundle(t, extensionPth, map[string]interface{}{"CFBundleExecutable": "Widget"})
+	// @executable_path refers to the extension's own executable in its process, not to the app's.
+	writeTestBinary(t, filepath.Join(extensionPth, "Widget"), arm64(
+		testRpath("@executable_path/../../Frameworks"),
+		testDylib(testLoadCmdLoadDylib, "@rpath/Lib.framework/Lib"),
+		testDylib(testLoadCmdLoadDylib, "@executable_path/Frameworks/Lib.framework/Lib"),
+	))
+
+	got, err := bundleDylibProblems(appPth, "App")
+	if err != nil {
+		t.Fatalf("bundleDylibProblems() error = %v", err)
+	}
+	want := []string{
+		"App.app/App.debug.dylib: library not found: @rpath/Missing.framework/Missing (searched rpaths: App.app, App.app/Frameworks)",
+		"App.app/PlugIns/Widget.appex/Widget: library not found: @executable_path/Frameworks/Lib.framework/Lib",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bundleDylibProblems() = %q, want %q", got, want)
+	}
+
+	if err := os.Remove(filepath.Join(appPth, "App.debug.dylib")); err != nil {
+		t.Fatal(err)
+	}
+	got, err = bundleDylibProblems(appPth, "App")
+	if err != nil {
+		t.Fatalf("bundleDylibProblems() error = %v", err)
+	}
+	if len(got) != 2 || !strings.Contains(got[0], "@rpath/App.debug.dylib") {
+		t.Errorf("bundleDylibProblems() = %q, want the missing debug dylib reported", got)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// bundleFilter decides which of the found app bundles get exported.
+// Names are matched against the bundle's directory name (with or without the .app extension),
+// identifiers against CFBundleIdentifier. Both use glob patterns, for example `*Tests*` or `com.example.*`.
+type bundleFilter struct {
+	IncludeNames []string
+	ExcludeNames []string
+	IncludeIDs   []string
+	ExcludeIDs   []string
+}
+
+func newBundleFilter(includeNames, excludeNames, includeIDs, excludeIDs string) (bundleFilter, error) {
+	filter := bundleFilter{}
+	for _, input := range []struct {
+		name     string
+		value    string
+		patterns *[]string
+	}{
+		{name: "include_bundle_names", value: includeNames, patterns: &filter.IncludeNames},
+		{name: "exclude_bundle_names", value: excludeNames, patterns: &filter.ExcludeNames},
+		{name: "include_bundle_ids", value: includeIDs, patterns: &filter.IncludeIDs},
+		{name: "exclude_bundle_ids", value: excludeIDs, patterns: &filter.ExcludeIDs},
+	} {
+		patterns, err := parsePatterns(input.value)
+		if err != nil {
+			return bundleFilter{}, fmt.Errorf("invalid `%s`: %s", input.name, err)
+		}
+		*input.patterns = patterns
+	}
+	return filter, nil
+}
+
+// parsePatterns splits a newline separated list of glob patterns.
+func parsePatterns(value string) ([]string, error) {
+	var patterns []string
+	for _, line := range strings.Split(value, "\n") {
+		pattern := strings.TrimSpace(line)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("pattern (%s): %s", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+func (f bundleFilter) isEmpty() bool {
+	return len(f.IncludeNames) == 0 && len(f.ExcludeNames) == 0 && len(f.IncludeIDs) == 0 && len(f.ExcludeIDs) == 0
+}
+
+// filterAppBundles returns the bundles kept by the filter, logging why each bundle was kept or skipped.
+func filterAppBundles(bundles []foundBundle, filter bundleFilter) ([]foundBundle, error) {
+	if filter.isEmpty() {
+		return bundles, nil
+	}
+
+	var kept []foundBundle
+	for _, bundle := range bundles {
+		keep, reason := filter.match(bundle)
+		if keep {
+			log.Printf("Keeping %s: %s", filepath.Base(bundle.Path), reason)
+			kept = append(kept, bundle)
+		} else {
+			log.Printf("Skipping %s: %s", filepath.Base(bundle.Path), reason)
+		}
+	}
+
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("all %d app bundles were removed by the include/exclude filters", len(bundles))
+	}
+
+	return kept, nil
+}
+
+func (f bundleFilter) match(bundle foundBundle) (bool, string) {
+	dirName := filepath.Base(bundle.Path)
+	name := strings.TrimSuffix(dirName, filepath.Ext(dirName))
+	bundleID := bundle.Info.BundleID
+
+	if bundleID == "" && (len(f.IncludeIDs) > 0 || len(f.ExcludeIDs) > 0) {
+		reason := "CFBundleIdentifier is missing from its Info.plist"
+		if bundle.InfoErr != nil {
+			reason = fmt.Sprintf("failed to read its Info.plist: %s", bundle.InfoErr)
+		}
+		log.Warnf("The bundle id of %s is unknown, the bundle id filters can't be applied to it: %s", bundle.Path, reason)
+	}
+
+	if pattern, ok := matchAny(f.ExcludeNames, dirName, name); ok {
+		return false, fmt.Sprintf("name matches exclude pattern (%s)", pattern)
+	}
+	if bundleID != "" {
+		if pattern, ok := matchAny(f.ExcludeIDs, bundleID); ok {
+			return false, fmt.Sprintf("bundle id (%s) matches exclude pattern (%s)", bundleID, pattern)
+		}
+	}
+
+	var reasons []string
+	if len(f.IncludeNames) > 0 {
+		pattern, ok := matchAny(f.IncludeNames, dirName, name)
+		if !ok {
+			return false, "name doesn't match any include pattern"
+		}
+		reasons = append(reasons, fmt.Sprintf("name matches include pattern (%s)", pattern))
+	}
+	if len(f.IncludeIDs) > 0 {
+		if bundleID == "" {
+			return false, "bundle id is unknown, it can't match any include pattern"
+		}
+		pattern, ok := matchAny(f.IncludeIDs, bundleID)
+		if !ok {
+			return false, fmt.Sprintf("bundle id (%s) doesn't match any include pattern", bundleID)
+		}
+		reasons = append(reasons, fmt.Sprintf("bundle id (%s) matches include pattern (%s)", bundleID, pattern))
+	}
+
+	if len(reasons) == 0 {
+		if len(f.ExcludeIDs) > 0 && bundleID == "" {
+			return true, "bundle id is unknown, it can't match any exclude pattern"
+		}
+		return true, "no exclude pattern matches"
+	}
+	return true, strings.Join(reasons, ", ")
+}
+
+// matchAny returns the first pattern matching any of the values.
+func matchAny(patterns []string, values ...string) (string, bool) {
+	for _, pattern := range patterns {
+		for _, value := range values {
+			if ok, _ := path.Match(pattern, value); ok {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appbundle"
+)
+
+func TestNewBundleFilter(t *testing.T) {
+	tests := []struct {
+		name         string
+		includeNames string
+		excludeIDs   string
+		want         bundleFilter
+		wantErr      bool
+	}{
+		{name: "empty", want: bundleFilter{}},
+		{
+			name:         "newline separated patterns",
+			includeNames: "App\n\n  *Tests*  \n",
+			excludeIDs:   "com.example.*",
+			want:         bundleFilter{IncludeNames: []string{"App", "*Tests*"}, ExcludeIDs: []string{"com.example.*"}},
+		},
+		{name: "invalid pattern", includeNames: "App[", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newBundleFilter(tt.includeNames, "", "", tt.excludeIDs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newBundleFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newBundleFilter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBundleFilterMatch(t *testing.T) {
+	app := foundBundle{Path: "/build/App.app", Info: appbundle.Info{BundleID: "io.bitrise.App"}}
+	tests := foundBundle{Path: "/build/AppUITests-Runner.app", Info: appbundle.Info{BundleID: "io.bitrise.AppUITests.xctrunner"}}
+	noID := foundBundle{Path: "/build/NoID.app"}
+	unreadable := foundBundle{Path: "/build/Broken.app", InfoErr: errors.New("no such file")}
+
+	cases := []struct {
+		name     string
+		filter   bundleFilter
+		bundle   foundBundle
+		wantKeep bool
+	}{
+		{name: "exclude by name", filter: bundleFilter{ExcludeNames: []string{"*Tests*"}}, bundle: tests, wantKeep: false},
+		{name: "exclude by name with extension", filter: bundleFilter{ExcludeNames: []string{"App.app"}}, bundle: app, wantKeep: false},
+		{name: "not excluded by name", filter: bundleFilter{ExcludeNames: []string{"*Tests*"}}, bundle: app, wantKeep: true},
+		{name: "include by name", filter: bundleFilter{IncludeNames: []string{"App"}}, bundle: app, wantKeep: true},
+		{name: "not included by name", filter: bundleFilter{IncludeNames: []string{"App"}}, bundle: tests, wantKeep: false},
+		{name: "exclude by id", filter: bundleFilter{ExcludeIDs: []string{"*.xctrunner"}}, bundle: tests, wantKeep: false},
+		{name: "include by id", filter: bundleFilter{IncludeIDs: []string{"io.bitrise.*"}}, bundle: app, wantKeep: true},
+		{name: "exclude wins over include", filter: bundleFilter{IncludeIDs: []string{"io.bitrise.*"}, ExcludeNames: []string{"App"}}, bundle: app, wantKeep: false},
+		{name: "both includes must match", filter: bundleFilter{IncludeNames: []string{"App"}, IncludeIDs: []string{"com.example.*"}}, bundle: app, wantKeep: false},
+		{name: "missing id is not included", filter: bundleFilter{IncludeIDs: []string{"*"}}, bundle: noID, wantKeep: false},
+		{name: "missing id is not excluded", filter: bundleFilter{ExcludeIDs: []string{"*"}}, bundle: noID, wantKeep: true},
+		{name: "unreadable Info.plist is not included", filter: bundleFilter{IncludeIDs: []string{"*"}}, bundle: unreadable, wantKeep: false},
+		{name: "unreadable Info.plist is not excluded", filter: bundleFilter{ExcludeIDs: []string{"*"}}, bundle: unreadable, wantKeep: true},
+		{name: "name filters don't need the id", filter: bundleFilter{IncludeNames: []string{"Broken"}}, bundle: unreadable, wantKeep: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			keep, reason := tt.filter.match(tt.bundle)
+			if keep != tt.wantKeep {
+				t.Errorf("match() = %v (%s), want %v", keep, reason, tt.wantKeep)
+			}
+			if reason == "" {
+				t.Errorf("match() returned no reason")
+			}
+		})
+	}
+}
+
+func TestFilterAppBundles(t *testing.T) {
+	bundles := []foundBundle{
+		{Path: "/build/App.app", Info: appbundle.Info{BundleID: "io.bitrise.App"}},
+		{Path: "/build/AppUITests-Runner.app", Info: appbundle.Info{BundleID: "io.bitrise.AppUITests.xctrunner"}},
+	}
+
+	tests := []struct {
+		name    string
+		filter  bundleFilter
+		want    []string
+		wantErr bool
+	}{
+		{name: "no filter", filter: bundleFilter{}, want: []string{"/build/App.app", "/build/AppUITests-Runner.app"}},
+		{name: "test runner excluded", filter: bundleFilter{ExcludeIDs: []string{"*.xctrunner"}}, want: []string{"/build/App.app"}},
+		{name: "everything excluded", filter: bundleFilter{ExcludeNames: []string{"*"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, err := filterAppBundles(bundles, tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("filterAppBundles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, bundle := range kept {
+				got = append(got, bundle.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterAppBundles() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,14 +9,16 @@ require (
 	github.com/bitrise-io/go-utils/v2 v2.0.0-alpha.34
 	github.com/bitrise-io/go-xcode v1.3.0
 	github.com/bitrise-io/go-xcode/v2 v2.0.0-alpha.67
+	github.com/hashicorp/go-retryablehttp v0.7.7
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51
+	howett.net/plist v1.0.0
 )
 
 require (
+	github.com/bitrise-io/go-plist v0.0.0-20210301100253-4b1a112ccd10 // indirect
 	github.com/ebitengine/purego v0.8.4 // indirect
 	github.com/go-ole/go-ole v1.2.6 // indirect
 	github.com/hashicorp/go-cleanhttp v0.5.2 // indirect
-	github.com/hashicorp/go-retryablehttp v0.7.7 // indirect
 	github.com/hashicorp/go-version v1.6.0 // indirect
 	github.com/lufia/plan9stats v0.0.0-20211012122336-39d0f177ccd0 // indirect
 	github.com/power-devops/perfstat v0.0.0-20210106213030-5aafc221ea8c // indirect
@@ -25,4 +27,5 @@ require (
 	github.com/tklauser/numcpus v0.10.0 // indirect
 	github.com/yusufpapurcu/wmi v1.2.4 // indirect
 	golang.org/x/sys v0.41.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
 )
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appbundle"
+)
+
+// embeddedBundlePatterns are the locations of the bundles an app embeds:
+// app extensions, watch apps and app clips.
+var embeddedBundlePatterns = []string{
+	filepath.Join("PlugIns", "*.appex"),
+	filepath.Join("Extensions", "*.appex"),
+	filepath.Join("Watch", "*.app"),
+	filepath.Join("AppClips", "*.app"),
+}
+
+// installabilityIssue is a reason the simulator would refuse to install a bundle.
+type installabilityIssue struct {
+	Bundle string
+	Key    string
+	Reason string
+}
+
+func (i installabilityIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Bundle, i.Key, i.Reason)
+}
+
+// checkInstallability validates the exported bundles the way a simulator does when installing them.
+// An embedded app exported on its own (see Artifact.HostPath) is checked against its exported host.
+func checkInstallability(artifacts []Artifact, mode string) error {
+	var issues []installabilityIssue
+	for _, artifact := range artifacts {
+		var host *appbundle.Info
+		if artifact.HostPath != "" {
+			if hostIdx := slices.IndexFunc(artifacts, func(a Artifact) bool { return a.Path == artifact.HostPath }); hostIdx >= 0 && artifacts[hostIdx].InfoErr == nil {
+				host = &artifacts[hostIdx].Info
+			}
+		}
+
+		bundleIssues := bundleInstallabilityIssues(artifact.Path, filepath.Dir(artifact.Path), artifact.Info, artifact.InfoErr, host)
+		if len(bundleIssues) == 0 {
+			log.Printf("%s: installable", filepath.Base(artifact.Path))
+		}
+		issues = append(issues, bundleIssues...)
+	}
+
+	if len(issues) == 0 {
+		log.Donef("All bundles can be installed on a simulator")
+		return nil
+	}
+
+	for _, issue := range issues {
+		if mode == binaryCheckFail {
+			log.Errorf("- %s", issue)
+		} else {
+			log.Warnf("- %s", issue)
+		}
+	}
+	if mode == binaryCheckFail {
+		return fmt.Errorf("%d installability issue(s) found", len(issues))
+	}
+	return nil
+}
+
+// bundleInstallabilityIssues checks the bundle and the bundles embedded in it.
+// info and infoErr are the result of reading the bundle's Info.plist,
+// host is the Info.plist of the bundle embedding this one, if any.
+func bundleInstallabilityIssues(bundlePth, baseDir string, info appbundle.Info, infoErr error, host *appbundle.Info) []installabilityIssue {
+	name, err := filepath.Rel(baseDir, bundlePth)
+	if err != nil {
+		name = bundlePth
+	}
+
+	if infoErr != nil {
+		return []installabilityIssue{{Bundle: name, Key: "Info.plist", Reason: fmt.Sprintf("failed to parse: %s", infoErr)}}
+	}
+
+	var issues []installabilityIssue
+	addIssue := func(key, format string, args ...interface{}) {
+		issues = append(issues, installabilityIssue{Bundle: name, Key: key, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if info.BundleID == "" {
+		addIssue("CFBundleIdentifier", "missing")
+	}
+
+	if info.Executable == "" {
+		addIssue("CFBundleExecutable", "missing")
+	} else if fileInfo, err := os.Stat(filepath.Join(bundlePth, info.Executable)); err != nil {
+		addIssue("CFBundleExecutable", "executable (%s) not found", info.Executable)
+	} else if !fileInfo.Mode().IsRegular() || fileInfo.Mode().Perm()&0111 == 0 {
+		addIssue("CFBundleExecutable", "executable (%s) is not an executable file (mode: %s)", info.Executable, fileInfo.Mode())
+	}
+
+	if info.PlatformName == "" {
+		addIssue("DTPlatformName", "missing")
+	} else if !strings.HasSuffix(strings.ToLower(info.PlatformName), "simulator") {
+		addIssue("DTPlatformName", "%s is not a simulator platform", info.PlatformName)
+	}
+
+	if len(info.SupportedPlatforms) == 0 {
+		addIssue("CFBundleSupportedPlatforms", "missing")
+	} else if !containsSimulatorPlatform(info.SupportedPlatforms) {
+		addIssue("CFBundleSupportedPlatforms", "none of %s is a simulator platform", strings.Join(info.SupportedPlatforms, ", "))
+	}
+
+	if host != nil {
+		if host.BundleID != "" && info.BundleID != "" && !strings.HasPrefix(info.BundleID, host.BundleID+".") {
+			addIssue("CFBundleIdentifier", "%s is not prefixed with the host's identifier (%s.)", info.BundleID, host.BundleID)
+		}
+		if info.ShortVersion != host.ShortVersion {
+			addIssue("CFBundleShortVersionString", "%s doesn't match the host's %s", info.ShortVersion, host.ShortVersion)
+		}
+		if info.BuildNumber != host.BuildNumber {
+			addIssue("CFBundleVersion", "%s doesn't match the host's %s", info.BuildNumber, host.BuildNumber)
+		}
+	}
+
+	for _, pattern := range embeddedBundlePatterns {
+		embedded, err := filepath.Glob(filepath.Join(bundlePth, pattern))
+		if err != nil {
+			continue
+		}
+		for _, embeddedPth := range embedded {
+			embeddedInfo, err := appbundle.ReadInfo(embeddedPth)
+			issues = append(issues, bundleInstallabilityIssues(embeddedPth, baseDir, embeddedInfo, err, &info)...)
+		}
+	}
+
+	return issues
+}
+
+func containsSimulatorPlatform(platforms []string) bool {
+	for _, platform := range platforms {
+		if strings.HasSuffix(platform, "Simulator") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appbundle"
+)
+
+// writeInstallableBundle writes a bundle a simulator would install, with the given Info.plist values overridden.
+func writeInstallableBundle(t *testing.T, pth, bundleID string, overrides map[string]interface{}) {
+	t.Helper()
+
+	executable := strings.TrimSuffix(filepath.Base(pth), filepath.Ext(pth))
+	info := map[string]interface{}{
+		"CFBundleIdentifier":         bundleID,
+		"CFBundleExecutable":         executable,
+		"CFBundleShortVersionString": "1.0",
+		"CFBundleVersion":            "1",
+		"DTPlatformName":             "iphonesimulator",
+		"CFBundleSupportedPlatforms": []string{"iPhoneSimulator"},
+	}
+	maps.Copy(info, overrides)
+	writeTestBundle(t, pth, info)
+	if err := os.WriteFile(filepath.Join(pth, executable), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBundleInstallabilityIssues(t *testing.T) {
+	type issue struct {
+		bundle, key string
+	}
+	tests := []struct {
+		name      string
+		overrides map[string]map[string]interface{}
+		modify    func(t *testing.T, appPth string)
+		want      []issue
+	}{
+		{
+			name: "installable",
+		},
+		{
+			name: "missing executable",
+			modify: func(t *testing.T, appPth string) {
+				if err := os.Remove(filepath.Join(appPth, "App")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []issue{{bundle: "App.app", key: "CFBundleExecutable"}},
+		},
+		{
+			name: "executable without the executable bit",
+			modify: func(t *testing.T, appPth string) {
+				if err := os.Chmod(filepath.Join(appPth, "PlugIns", "Widget.appex", "Widget"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []issue{{bundle: "App.app/PlugIns/Widget.appex", key: "CFBundleExecutable"}},
+		},
+		{
+			name:      "device platform in CFBundleSupportedPlatforms",
+			overrides: map[string]map[string]interface{}{"": {"CFBundleSupportedPlatforms": []string{"iPhoneOS"}}},
+			want:      []issue{{bundle: "App.app", key: "CFBundleSupportedPlatforms"}},
+		},
+		{
+			name:      "device platform in DTPlatformName",
+			overrides: map[string]map[string]interface{}{"Watch/Watch.app": {"DTPlatformName": "watchos"}},
+			want:      []issue{{bundle: "App.app/Watch/Watch.app", key: "DTPlatformName"}},
+		},
+		{
+			name:      "embedded bundle id without the host prefix",
+			overrides: map[string]map[string]interface{}{"PlugIns/Widget.appex": {"CFBundleIdentifier": "io.bitrise.AppWidget"}},
+			want:      []issue{{bundle: "App.app/PlugIns/Widget.appex", key: "CFBundleIdentifier"}},
+		},
+		{
+			name:      "embedded short version differs from the host",
+			overrides: map[string]map[string]interface{}{"PlugIns/Widget.appex": {"CFBundleShortVersionString": "1.1"}},
+			want:      []issue{{bundle: "App.app/PlugIns/Widget.appex", key: "CFBundleShortVersionString"}},
+		},
+		{
+			name:      "embedded version differs from the host",
+			overrides: map[string]map[string]interface{}{"Watch/Watch.app": {"CFBundleVersion": "2"}},
+			want: []issue{
+				{bundle: "App.app/Watch/Watch.app", key: "CFBundleVersion"},
+				// The watch app's extension is checked against the watch app.
+				{bundle: "App.app/Watch/Watch.app/PlugIns/Complication.appex", key: "CFBundleVersion"},
+			},
+		},
+		{
+			name:      "extension of the embedded watch app is checked against the watch app",
+			overrides: map[string]map[string]interface{}{"Watch/Watch.app/PlugIns/Complication.appex": {"CFBundleIdentifier": "io.bitrise.App.Complication"}},
+			want:      []issue{{bundle: "App.app/Watch/Watch.app/PlugIns/Complication.appex", key: "CFBundleIdentifier"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			appPth := filepath.Join(dir, "App.app")
+			for rel, bundleID := range map[string]string{
+				"":                     "io.bitrise.App",
+				"PlugIns/Widget.appex": "io.bitrise.App.Widget",
+				"Watch/Watch.app":      "io.bitrise.App.watchkitapp",
+				"Watch/Watch.app/PlugIns/Complication.appex": "io.bitrise.App.watchkitapp.Complication",
+			} {
+				writeInstallableBundle(t, filepath.Join(appPth, rel), bundleID, tt.overrides[rel])
+			}
+			if tt.modify != nil {
+				tt.modify(t, appPth)
+			}
+
+			info, infoErr := appbundle.ReadInfo(appPth)
+			var got []issue
+			for _, i := range bundleInstallabilityIssues(appPth, dir, info, infoErr, nil) {
+				got = append(got, issue{bundle: i.Bundle, key: i.Key})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bundleInstallabilityIssues() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckInstallabilityEmbeddedAppHost(t *testing.T) {
+	tests := []struct {
+		name         string
+		watchVersion string
+		hostExported bool
+		wantErr      bool
+	}{
+		{name: "matching the exported host", watchVersion: "1", hostExported: true},
+		{name: "differing from the exported host", watchVersion: "2", hostExported: true, wantErr: true},
+		{name: "host not exported", watchVersion: "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			appPth := filepath.Join(dir, "App.app")
+			watchPth := filepath.Join(dir, "Watch.app")
+			writeInstallableBundle(t, appPth, "io.bitrise.App", nil)
+			writeInstallableBundle(t, watchPth, "io.bitrise.App.watchkitapp", map[string]interface{}{"CFBundleVersion": tt.watchVersion})
+
+			var artifacts []Artifact
+			for _, pth := range []string{appPth, watchPth} {
+				info, err := appbundle.ReadInfo(pth)
+				if err != nil {
+					t.Fatal(err)
+				}
+				artifacts = append(artifacts, Artifact{Path: pth, Info: info})
+			}
+			artifacts[1].Host = "App.app"
+			if tt.hostExported {
+				artifacts[1].HostPath = appPth
+			} else {
+				artifacts = artifacts[1:]
+			}
+
+			if err := checkInstallability(artifacts, binaryCheckFail); (err != nil) != tt.wantErr {
+				t.Errorf("checkInstallability() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package machoinfo
+
+import (
+	"bytes"
+	"debug/macho"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Load commands not defined by debug/macho.
+const (
+	loadCmdVersionMinMacOS   macho.LoadCmd = 0x24
+	loadCmdVersionMinIOS     macho.LoadCmd = 0x25
+	loadCmdVersionMinTVOS    macho.LoadCmd = 0x2f
+	loadCmdVersionMinWatchOS macho.LoadCmd = 0x30
+	loadCmdBuildVersion      macho.LoadCmd = 0x32
+	loadCmdLoadWeakDylib     macho.LoadCmd = 0x80000018
+	loadCmdReexportDylib     macho.LoadCmd = 0x8000001f
+	loadCmdLazyLoadDylib     macho.LoadCmd = 0x20
+	loadCmdLoadUpwardDylib   macho.LoadCmd = 0x80000023
+)
+
+// Dylib is a dynamic library a binary links against, for example @rpath/Alamofire.framework/Alamofire.
+type Dylib struct {
+	Name string
+	// Weak libraries may be missing at runtime.
+	Weak bool
+}
+
+// Slice is a single architecture of a (fat) Mach-O binary.
+type Slice struct {
+	Arch     string
+	Platform Platform
+	MinOS    string
+	Dylibs   []Dylib
+	Rpaths   []string
+}
+
+func (s Slice) String() string {
+	if s.Platform == PlatformUnknown {
+		return s.Arch
+	}
+	if s.MinOS == "" {
+		return fmt.Sprintf("%s (%s)", s.Arch, s.Platform)
+	}
+	return fmt.Sprintf("%s (%s %s)", s.Arch, s.Platform, s.MinOS)
+}
+
+// Binary is a Mach-O file, either thin (a single slice) or fat.
+type Binary struct {
+	Path   string
+	Slices []Slice
+}
+
+// Arches returns the architectures of the binary's slices.
+func (b Binary) Arches() []string {
+	var arches []string
+	for _, slice := range b.Slices {
+		arches = append(arches, slice.Arch)
+	}
+	return arches
+}
+
+// HasArch ...
+func (b Binary) HasArch(arch string) bool {
+	for _, slice := range b.Slices {
+		if slice.Arch == arch {
+			return true
+		}
+	}
+	return false
+}
+
+func (b Binary) String() string {
+	var slices []string
+	for _, slice := range b.Slices {
+		slices = append(slices, slice.String())
+	}
+	return strings.Join(slices, ", ")
+}
+
+// Inspect reads the slices of a thin or fat Mach-O binary.
+func Inspect(pth string) (Binary, error) {
+	binary := Binary{Path: pth}
+
+	fat, err := macho.OpenFat(pth)
+	if err == nil {
+		defer fat.Close()
+
+		for _, arch := range fat.Arches {
+			binary.Slices = append(binary.Slices, inspectFile(arch.File))
+		}
+		return binary, nil
+	}
+	if !errors.Is(err, macho.ErrNotFat) {
+		return Binary{}, err
+	}
+
+	file, err := macho.Open(pth)
+	if err != nil {
+		return Binary{}, err
+	}
+	defer file.Close()
+
+	binary.Slices = append(binary.Slices, inspectFile(file))
+	return binary, nil
+}
+
+func inspectFile(file *macho.File) Slice {
+	slice := Slice{Arch: archName(file.Cpu, file.SubCpu)}
+
+	for _, load := range file.Loads {
+		raw := load.Raw()
+		if len(raw) < 16 {
+			continue
+		}
+
+		cmd := macho.LoadCmd(file.ByteOrder.Uint32(raw[0:4]))
+		switch cmd {
+		case loadCmdBuildVersion:
+			// struct build_version_command { cmd, cmdsize, platform, minos, sdk, ntools }
+			slice.Platform = Platform(file.ByteOrder.Uint32(raw[8:12]))
+			slice.MinOS = formatVersion(file.ByteOrder.Uint32(raw[12:16]))
+		case loadCmdVersionMinMacOS, loadCmdVersionMinIOS, loadCmdVersionMinTVOS, loadCmdVersionMinWatchOS:
+			// struct version_min_command { cmd, cmdsize, version, sdk }
+			// Older toolchains used these for simulator builds as well, only the architecture tells them apart.
+			if slice.Platform == PlatformUnknown {
+				slice.Platform = versionMinPlatform(cmd, slice.Arch)
+				slice.MinOS = formatVersion(file.ByteOrder.Uint32(raw[8:12]))
+			}
+		case macho.LoadCmdDylib, loadCmdLoadWeakDylib, loadCmdReexportDylib, loadCmdLazyLoadDylib, loadCmdLoadUpwardDylib:
+			// struct dylib_command { cmd, cmdsize, struct dylib { name offset, timestamp, current_version, compatibility_version } }
+			if name, ok := loadCommandString(raw, file.ByteOrder.Uint32(raw[8:12])); ok {
+				slice.Dylibs = append(slice.Dylibs, Dylib{Name: name, Weak: cmd == loadCmdLoadWeakDylib})
+			}
+		case macho.LoadCmdRpath:
+			// struct rpath_command { cmd, cmdsize, path offset }
+			if pth, ok := loadCommandString(raw, file.ByteOrder.Uint32(raw[8:12])); ok {
+				slice.Rpaths = append(slice.Rpaths, pth)
+			}
+		}
+	}
+
+	return slice
+}
+
+// loadCommandString reads the NUL terminated string at the offset of a load command.
+func loadCommandString(raw []byte, offset uint32) (string, bool) {
+	if offset >= uint32(len(raw)) {
+		return "", false
+	}
+	value := raw[offset:]
+	if i := bytes.IndexByte(value, 0); i >= 0 {
+		value = value[:i]
+	}
+	return string(value), true
+}
+
+func archName(cpu macho.Cpu, subCPU uint32) string {
+	const cpuSubtypeMask = 0x00ffffff
+	const cpuSubtypeARM64E = 2
+
+	switch cpu {
+	case macho.CpuArm64:
+		if subCPU&cpuSubtypeMask == cpuSubtypeARM64E {
+			return "arm64e"
+		}
+		return "arm64"
+	case macho.CpuAmd64:
+		return "x86_64"
+	case macho.Cpu386:
+		return "i386"
+	case macho.CpuArm:
+		return "armv7"
+	}
+	return cpu.String()
+}
+
+func versionMinPlatform(cmd macho.LoadCmd, arch string) Platform {
+	intel := arch == "x86_64" || arch == "i386"
+	switch cmd {
+	case loadCmdVersionMinMacOS:
+		return PlatformMacOS
+	case loadCmdVersionMinIOS:
+		if intel {
+			return PlatformIOSSimulator
+		}
+		return PlatformIOS
+	case loadCmdVersionMinTVOS:
+		if intel {
+			return PlatformTVOSSimulator
+		}
+		return PlatformTVOS
+	case loadCmdVersionMinWatchOS:
+		if intel {
+			return PlatformWatchOSSimulator
+		}
+		return PlatformWatchOS
+	}
+	return PlatformUnknown
+}
+
+// formatVersion formats a version encoded as xxxx.yy.zz (16, 8 and 8 bits).
+func formatVersion(version uint32) string {
+	major, minor, patch := version>>16, (version>>8)&0xff, version&0xff
+	if patch == 0 {
+		return fmt.Sprintf("%d.%d", major, minor)
+	}
+	return fmt.Sprintf("%d.%d.%d", major, minor, patch)
+}
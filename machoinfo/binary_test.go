@@ -0,0 +1,247 @@
+package machoinfo
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const (
+	loadCmdLoadDylib = 0xc
+	loadCmdRpath     = 0x8000001c
+)
+
+// loadCommand encodes a load command, padding it to 8 bytes like the linker does.
+func loadCommand(cmd uint32, fields []uint32, str string) []byte {
+	var b bytes.Buffer
+	size := 8 + 4*len(fields)
+	if str != "" {
+		size += len(str) + 1
+	}
+	size = (size + 7) &^ 7
+
+	_ = binary.Write(&b, binary.LittleEndian, []uint32{cmd, uint32(size)})
+	_ = binary.Write(&b, binary.LittleEndian, fields)
+	if str != "" {
+		b.WriteString(str)
+	}
+	b.Write(make([]byte, size-b.Len()))
+	return b.Bytes()
+}
+
+func buildVersion(platform Platform, minOS uint32) []byte {
+	return loadCommand(uint32(loadCmdBuildVersion), []uint32{uint32(platform), minOS, minOS, 0}, "")
+}
+
+func versionMin(cmd macho.LoadCmd, version uint32) []byte {
+	return loadCommand(uint32(cmd), []uint32{version, version}, "")
+}
+
+func dylib(cmd uint32, name string) []byte {
+	// struct dylib_command { cmd, cmdsize, name offset, timestamp, current_version, compatibility_version }
+	return loadCommand(cmd, []uint32{24, 0, 0x10000, 0x10000}, name)
+}
+
+func rpath(pth string) []byte {
+	return loadCommand(loadCmdRpath, []uint32{12}, pth)
+}
+
+// thinBinary encodes a 64-bit little endian Mach-O executable with the given load commands.
+func thinBinary(cpu macho.Cpu, subCPU uint32, cmds ...[]byte) []byte {
+	var commands []byte
+	for _, cmd := range cmds {
+		commands = append(commands, cmd...)
+	}
+
+	var b bytes.Buffer
+	_ = binary.Write(&b, binary.LittleEndian, []uint32{
+		macho.Magic64, uint32(cpu), subCPU, uint32(macho.TypeExec), uint32(len(cmds)), uint32(len(commands)), 0, 0,
+	})
+	b.Write(commands)
+	return b.Bytes()
+}
+
+// fatBinary encodes a universal binary of the given thin binaries.
+func fatBinary(slices ...[]byte) []byte {
+	const align = 12
+	offset := uint32(1 << align)
+
+	var header, content bytes.Buffer
+	_ = binary.Write(&header, binary.BigEndian, []uint32{macho.MagicFat, uint32(len(slices))})
+	for _, slice := range slices {
+		cpu := binary.LittleEndian.Uint32(slice[4:8])
+		subCPU := binary.LittleEndian.Uint32(slice[8:12])
+		_ = binary.Write(&header, binary.BigEndian, []uint32{cpu, subCPU, offset, uint32(len(slice)), align})
+
+		padded := make([]byte, (len(slice)+(1<<align)-1)&^((1<<align)-1))
+		copy(padded, slice)
+		content.Write(padded)
+		offset += uint32(len(padded))
+	}
+
+	out := make([]byte, 1<<align)
+	copy(out, header.Bytes())
+	return append(out, content.Bytes()...)
+}
+
+func writeBinary(t *testing.T, content []byte) string {
+	t.Helper()
+
+	pth := filepath.Join(t.TempDir(), "App")
+	if err := os.WriteFile(pth, content, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return pth
+}
+
+func TestInspect(t *testing.T) {
+	simulatorArm64 := thinBinary(macho.CpuArm64, 0,
+		buildVersion(PlatformIOSSimulator, 0x0f0000),
+		dylib(loadCmdLoadDylib, "/usr/lib/libSystem.B.dylib"),
+		dylib(uint32(loadCmdLoadWeakDylib), "@rpath/Optional.framework/Optional"),
+		rpath("@executable_path/Frameworks"),
+	)
+	legacyIntel := thinBinary(macho.CpuAmd64, 3,
+		versionMin(loadCmdVersionMinIOS, 0x0c0100),
+		dylib(loadCmdLoadDylib, "/usr/lib/libSystem.B.dylib"),
+	)
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    []Slice
+		wantErr bool
+	}{
+		{
+			name:    "thin simulator binary",
+			content: simulatorArm64,
+			want: []Slice{{
+				Arch:     "arm64",
+				Platform: PlatformIOSSimulator,
+				MinOS:    "15.0",
+				Dylibs:   []Dylib{{Name: "/usr/lib/libSystem.B.dylib"}, {Name: "@rpath/Optional.framework/Optional", Weak: true}},
+				Rpaths:   []string{"@executable_path/Frameworks"},
+			}},
+		},
+		{
+			name:    "legacy intel simulator binary",
+			content: legacyIntel,
+			want: []Slice{{
+				Arch:     "x86_64",
+				Platform: PlatformIOSSimulator,
+				MinOS:    "12.1",
+				Dylibs:   []Dylib{{Name: "/usr/lib/libSystem.B.dylib"}},
+			}},
+		},
+		{
+			name:    "device binary",
+			content: thinBinary(macho.CpuArm64, 2, versionMin(loadCmdVersionMinIOS, 0x0e0201)),
+			want:    []Slice{{Arch: "arm64e", Platform: PlatformIOS, MinOS: "14.2.1"}},
+		},
+		{
+			name:    "build version wins over version min",
+			content: thinBinary(macho.CpuArm64, 0, buildVersion(PlatformWatchOSSimulator, 0x0a0000), versionMin(loadCmdVersionMinWatchOS, 0x070000)),
+			want:    []Slice{{Arch: "arm64", Platform: PlatformWatchOSSimulator, MinOS: "10.0"}},
+		},
+		{
+			name:    "fat binary",
+			content: fatBinary(legacyIntel, simulatorArm64),
+			want: []Slice{
+				{Arch: "x86_64", Platform: PlatformIOSSimulator, MinOS: "12.1", Dylibs: []Dylib{{Name: "/usr/lib/libSystem.B.dylib"}}},
+				{
+					Arch:     "arm64",
+					Platform: PlatformIOSSimulator,
+					MinOS:    "15.0",
+					Dylibs:   []Dylib{{Name: "/usr/lib/libSystem.B.dylib"}, {Name: "@rpath/Optional.framework/Optional", Weak: true}},
+					Rpaths:   []string{"@executable_path/Frameworks"},
+				},
+			},
+		},
+		{
+			name:    "out of range string offset is skipped",
+			content: thinBinary(macho.CpuArm64, 0, loadCommand(uint32(loadCmdLoadWeakDylib), []uint32{0xffffffff, 0, 0, 0}, "")),
+			want:    []Slice{{Arch: "arm64"}},
+		},
+		{
+			name:    "not a Mach-O file",
+			content: []byte("#!/bin/sh\necho hello\n"),
+			wantErr: true,
+		},
+		{
+			name:    "truncated header",
+			content: simulatorArm64[:16],
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := writeBinary(t, tt.content)
+
+			got, err := Inspect(pth)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Inspect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Path != pth {
+				t.Errorf("Path = %s, want %s", got.Path, pth)
+			}
+			if !reflect.DeepEqual(got.Slices, tt.want) {
+				t.Errorf("Slices = %+v, want %+v", got.Slices, tt.want)
+			}
+		})
+	}
+}
+
+func TestBinary(t *testing.T) {
+	b := Binary{Slices: []Slice{
+		{Arch: "x86_64", Platform: PlatformIOSSimulator, MinOS: "12.1"},
+		{Arch: "arm64", Platform: PlatformIOSSimulator},
+		{Arch: "armv7"},
+	}}
+
+	if got, want := b.Arches(), []string{"x86_64", "arm64", "armv7"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Arches() = %q, want %q", got, want)
+	}
+	if !b.HasArch("arm64") || b.HasArch("arm64e") {
+		t.Errorf("HasArch() is wrong for %s", b)
+	}
+	if got, want := b.String(), "x86_64 (iOS Simulator 12.1), arm64 (iOS Simulator), armv7"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+
+func TestPlatform(t *testing.T) {
+	tests := []struct {
+		platform  Platform
+		name      string
+		simulator bool
+		device    bool
+	}{
+		{platform: PlatformIOS, name: "iOS", device: true},
+		{platform: PlatformIOSSimulator, name: "iOS Simulator", simulator: true},
+		{platform: PlatformWatchOSSimulator, name: "watchOS Simulator", simulator: true},
+		{platform: PlatformVisionOS, name: "visionOS", device: true},
+		{platform: PlatformMacCatalyst, name: "Mac Catalyst"},
+		{platform: PlatformUnknown, name: "unknown"},
+		{platform: Platform(99), name: "platform 99"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.platform.String(); got != tt.name {
+				t.Errorf("String() = %s, want %s", got, tt.name)
+			}
+			if got := tt.platform.IsSimulator(); got != tt.simulator {
+				t.Errorf("IsSimulator() = %v, want %v", got, tt.simulator)
+			}
+			if got := tt.platform.IsDevice(); got != tt.device {
+				t.Errorf("IsDevice() = %v, want %v", got, tt.device)
+			}
+		})
+	}
+}
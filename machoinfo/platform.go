@@ -0,0 +1,64 @@
+package machoinfo
+
+import "fmt"
+
+// Platform is the platform of a Mach-O binary, as stored in its LC_BUILD_VERSION load command.
+type Platform uint32
+
+// Platforms
+const (
+	PlatformUnknown           Platform = 0
+	PlatformMacOS             Platform = 1
+	PlatformIOS               Platform = 2
+	PlatformTVOS              Platform = 3
+	PlatformWatchOS           Platform = 4
+	PlatformBridgeOS          Platform = 5
+	PlatformMacCatalyst       Platform = 6
+	PlatformIOSSimulator      Platform = 7
+	PlatformTVOSSimulator     Platform = 8
+	PlatformWatchOSSimulator  Platform = 9
+	PlatformDriverKit         Platform = 10
+	PlatformVisionOS          Platform = 11
+	PlatformVisionOSSimulator Platform = 12
+)
+
+var platformNames = map[Platform]string{
+	PlatformUnknown:           "unknown",
+	PlatformMacOS:             "macOS",
+	PlatformIOS:               "iOS",
+	PlatformTVOS:              "tvOS",
+	PlatformWatchOS:           "watchOS",
+	PlatformBridgeOS:          "bridgeOS",
+	PlatformMacCatalyst:       "Mac Catalyst",
+	PlatformIOSSimulator:      "iOS Simulator",
+	PlatformTVOSSimulator:     "tvOS Simulator",
+	PlatformWatchOSSimulator:  "watchOS Simulator",
+	PlatformDriverKit:         "DriverKit",
+	PlatformVisionOS:          "visionOS",
+	PlatformVisionOSSimulator: "visionOS Simulator",
+}
+
+func (p Platform) String() string {
+	if name, ok := platformNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("platform %d", uint32(p))
+}
+
+// IsSimulator ...
+func (p Platform) IsSimulator() bool {
+	switch p {
+	case PlatformIOSSimulator, PlatformTVOSSimulator, PlatformWatchOSSimulator, PlatformVisionOSSimulator:
+		return true
+	}
+	return false
+}
+
+// IsDevice is true for the platforms of physical iOS, tvOS, watchOS and visionOS devices.
+func (p Platform) IsDevice() bool {
+	switch p {
+	case PlatformIOS, PlatformTVOS, PlatformWatchOS, PlatformVisionOS:
+		return true
+	}
+	return false
+}
@@ -21,6 +21,12 @@ func run() int {
 		return 1
 	}
 
+	runOpts, err = step.Preflight(runOpts)
+	if err != nil {
+		log.Errorf("Error validating inputs: %s", err)
+		return 1
+	}
+
 	runOpts, err = step.InstallDependencies(runOpts)
 	if err != nil {
 		log.Errorf("Error installing dependencies: %s", err)
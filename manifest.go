@@ -0,0 +1,208 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appbundle"
+)
+
+const artifactManifestFileName = "artifacts.json"
+
+type artifactManifest struct {
+	Artifacts []artifactManifestEntry `json:"artifacts"`
+}
+
+type artifactManifestEntry struct {
+	Path             string         `json:"path"`
+	Kind             appbundle.Kind `json:"kind"`
+	BundleID         string         `json:"bundle_id"`
+	DisplayName      string         `json:"display_name"`
+	ShortVersion     string         `json:"short_version"`
+	BuildNumber      string         `json:"build_number"`
+	PlatformName     string         `json:"platform_name"`
+	MinimumOSVersion string         `json:"minimum_os_version"`
+	Size             int64          `json:"size"`
+	Packages         []string       `json:"packages"`
+	Host             string         `json:"host,omitempty"`
+	HostPath         string         `json:"host_path,omitempty"`
+	PackagePath      string         `json:"package_path,omitempty"`
+	PackageSize      int64          `json:"package_size,omitempty"`
+	PackageSHA256    string         `json:"package_sha256,omitempty"`
+	BundleSHA256     string         `json:"bundle_sha256,omitempty"`
+}
+
+// writeArtifactManifest describes every exported bundle in a JSON file, so tools
+// don't have to rely on the bundle file names.
+func writeArtifactManifest(artifacts []Artifact, pth string) error {
+	manifest := artifactManifest{Artifacts: []artifactManifestEntry{}}
+
+	for _, artifact := range artifacts {
+		entry, err := newArtifactManifestEntry(artifact)
+		if err != nil {
+			return fmt.Errorf("failed to describe %s: %s", artifact.Path, err)
+		}
+		manifest.Artifacts = append(manifest.Artifacts, entry)
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(pth, content, 0644)
+}
+
+func newArtifactManifestEntry(artifact Artifact) (artifactManifestEntry, error) {
+	bundlePth := artifact.Path
+	entry := artifactManifestEntry{
+		Path:     bundlePth,
+		Kind:     appbundle.KindUnknown,
+		Packages: append([]string{}, artifact.Packages...),
+		Host:     artifact.Host,
+		HostPath: artifact.HostPath,
+	}
+
+	if info := artifact.Info; artifact.InfoErr == nil {
+		entry.Kind = appbundle.Classify(bundlePth, info)
+		entry.BundleID = info.BundleID
+		entry.DisplayName = info.DisplayName
+		if entry.DisplayName == "" {
+			entry.DisplayName = info.Name
+		}
+		entry.ShortVersion = info.ShortVersion
+		entry.BuildNumber = info.BuildNumber
+		entry.PlatformName = info.PlatformName
+		entry.MinimumOSVersion = info.MinimumOSVersion
+	}
+
+	size, err := dirSize(bundlePth)
+	if err != nil {
+		return artifactManifestEntry{}, err
+	}
+	entry.Size = size
+
+	// The first package (the zip, if both a zip and a tarball were created) is checksummed,
+	// the bundle itself if it wasn't packaged.
+	if len(artifact.Packages) == 0 {
+		checksum, err := dirSHA256(bundlePth)
+		if err != nil {
+			return artifactManifestEntry{}, err
+		}
+		entry.BundleSHA256 = checksum
+		return entry, nil
+	}
+
+	packagePth := artifact.Packages[0]
+	info, err := os.Stat(packagePth)
+	if err != nil {
+		return artifactManifestEntry{}, err
+	}
+	checksum, err := fileSHA256(packagePth)
+	if err != nil {
+		return artifactManifestEntry{}, err
+	}
+
+	entry.PackagePath = packagePth
+	entry.PackageSize = info.Size()
+	entry.PackageSHA256 = checksum
+
+	return entry, nil
+}
+
+// dirSize sums the size of the regular files in the dir, symlinks are not followed.
+func dirSize(pth string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(pth, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// dirSHA256 checksums the dir's layout and content: the relative path, type and permissions of every entry
+// in lexical order, the size and content of the regular files and the target of the symlinks.
+// Modification times are not part of the checksum, so copies of the same bundle have the same checksum.
+func dirSHA256(pth string) (string, error) {
+	hash := sha256.New()
+	err := filepath.WalkDir(pth, func(entryPth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(pth, entryPth)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(hash, "%s\x00%s\x00", filepath.ToSlash(rel), info.Mode()); err != nil {
+			return err
+		}
+
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(entryPth)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(hash, "%d\x00%s", len(target), target)
+			return err
+		case info.Mode().IsRegular():
+			if _, err := fmt.Fprintf(hash, "%d\x00", info.Size()); err != nil {
+				return err
+			}
+
+			file, err := os.Open(entryPth)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = file.Close()
+			}()
+
+			_, err = io.Copy(hash, file)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func fileSHA256(pth string) (string, error) {
+	file, err := os.Open(pth)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/util"
+)
+
+func TestNewArtifactManifestEntry(t *testing.T) {
+	tests := []struct {
+		name           string
+		packageFormats []string
+		wantPackage    string
+	}{
+		{name: "zip", packageFormats: []string{packageFormatZip}, wantPackage: "App.app.zip"},
+		{name: "tar.gz", packageFormats: []string{packageFormatTarGz}, wantPackage: "App.app.tar.gz"},
+		{name: "both", packageFormats: []string{packageFormatZip, packageFormatTarGz}, wantPackage: "App.app.zip"},
+		{name: "none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			bundle := filepath.Join(dir, "App.app")
+			writeTestBundle(t, bundle, map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App", "CFBundlePackageType": "APPL"})
+
+			artifact := Artifact{Path: bundle}
+			for _, format := range tt.packageFormats {
+				pth := bundle + "." + format
+				var err error
+				if format == packageFormatZip {
+					err = util.ZipDir(bundle, pth)
+				} else {
+					err = util.TarGzDir(bundle, pth)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				artifact.Packages = append(artifact.Packages, pth)
+			}
+
+			entry, err := newArtifactManifestEntry(artifact)
+			if err != nil {
+				t.Fatalf("newArtifactManifestEntry() error = %v", err)
+			}
+
+			if tt.wantPackage == "" {
+				if entry.PackagePath != "" || entry.PackageSize != 0 || entry.PackageSHA256 != "" {
+					t.Errorf("package = %s (%d, %s), want none", entry.PackagePath, entry.PackageSize, entry.PackageSHA256)
+				}
+				want, err := dirSHA256(bundle)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if entry.BundleSHA256 != want {
+					t.Errorf("BundleSHA256 = %s, want %s", entry.BundleSHA256, want)
+				}
+				return
+			}
+
+			wantPth := filepath.Join(dir, tt.wantPackage)
+			info, err := os.Stat(wantPth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantSHA256, err := fileSHA256(wantPth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.PackagePath != wantPth || entry.PackageSize != info.Size() || entry.PackageSHA256 != wantSHA256 {
+				t.Errorf("package = %s (%d, %s), want %s (%d, %s)", entry.PackagePath, entry.PackageSize, entry.PackageSHA256, wantPth, info.Size(), wantSHA256)
+			}
+			if entry.BundleSHA256 != "" {
+				t.Errorf("BundleSHA256 = %s, want none for a packaged bundle", entry.BundleSHA256)
+			}
+		})
+	}
+}
+
+func TestDirSHA256(t *testing.T) {
+	writeBundle := func(t *testing.T, modify func(pth string) error) string {
+		pth := filepath.Join(t.TempDir(), "App.app")
+		writeTestBundle(t, pth, map[string]interface{}{"CFBundleIdentifier": "io.bitrise.App"})
+		if err := os.WriteFile(filepath.Join(pth, "App"), []byte("binary"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink("App", filepath.Join(pth, "Link")); err != nil {
+			t.Fatal(err)
+		}
+		if modify != nil {
+			if err := modify(pth); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return pth
+	}
+
+	want, err := dirSHA256(writeBundle(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(pth string) error
+		wantSame bool
+	}{
+		{
+			name:     "same content",
+			wantSame: true,
+		},
+		{
+			name: "different modification time",
+			modify: func(pth string) error {
+				modTime := time.Now().Add(-24 * time.Hour)
+				return os.Chtimes(filepath.Join(pth, "App"), modTime, modTime)
+			},
+			wantSame: true,
+		},
+		{
+			name: "different content",
+			modify: func(pth string) error {
+				return os.WriteFile(filepath.Join(pth, "App"), []byte("binarY"), 0755)
+			},
+		},
+		{
+			name: "different permissions",
+			modify: func(pth string) error {
+				return os.Chmod(filepath.Join(pth, "App"), 0644)
+			},
+		},
+		{
+			name: "different symlink target",
+			modify: func(pth string) error {
+				if err := os.Remove(filepath.Join(pth, "Link")); err != nil {
+					return err
+				}
+				return os.Symlink("Info.plist", filepath.Join(pth, "Link"))
+			},
+		},
+		{
+			name: "content moved between files",
+			modify: func(pth string) error {
+				if err := os.WriteFile(filepath.Join(pth, "App"), []byte("bin"), 0755); err != nil {
+					return err
+				}
+				return os.WriteFile(filepath.Join(pth, "B"), []byte("ary"), 0644)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dirSHA256(writeBundle(t, tt.modify))
+			if err != nil {
+				t.Fatalf("dirSHA256() error = %v", err)
+			}
+			if (got == want) != tt.wantSame {
+				t.Errorf("dirSHA256() = %s, original %s, want same: %v", got, want, tt.wantSame)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	nameCollisionOverwrite = "overwrite"
+	nameCollisionFail      = "fail"
+	nameCollisionSuffix    = "suffix"
+)
+
+var (
+	namePlaceholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+	nameUnsafeCharacters   = regexp.MustCompile(`[/\\:]`)
+)
+
+// artifactNamePlaceholders are the values an artifact name template can refer to, like `{scheme}-{version}`.
+var artifactNamePlaceholders = []string{"name", "scheme", "configuration", "platform", "version", "build_number", "bundle_id"}
+
+func validateArtifactNameTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("empty template")
+	}
+	for _, match := range namePlaceholderPattern.FindAllStringSubmatch(template, -1) {
+		found := false
+		for _, placeholder := range artifactNamePlaceholders {
+			if match[1] == placeholder {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown placeholder (%s), available placeholders: {%s}", match[0], strings.Join(artifactNamePlaceholders, "}, {"))
+		}
+	}
+	return nil
+}
+
+// renderArtifactName fills in the template for the bundle. The .app extension is not part of the template.
+func renderArtifactName(template string, bundle foundBundle, scheme, configuration string) (string, error) {
+	dirName := filepath.Base(bundle.Path)
+	values := map[string]string{
+		"name":          strings.TrimSuffix(dirName, filepath.Ext(dirName)),
+		"scheme":        scheme,
+		"configuration": configuration,
+		"platform":      bundle.Info.PlatformName,
+		"version":       bundle.Info.ShortVersion,
+		"build_number":  bundle.Info.BuildNumber,
+		"bundle_id":     bundle.Info.BundleID,
+	}
+
+	name := namePlaceholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return values[strings.Trim(placeholder, "{}")]
+	})
+	name = strings.TrimSpace(nameUnsafeCharacters.ReplaceAllString(name, "-"))
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("artifact name template (%s) renders an empty name for %s", template, bundle.Path)
+	}
+
+	return name + filepath.Ext(dirName), nil
+}
+
+// artifactDestinations returns the deploy dir path of every bundle.
+// Bundles rendered to the same name, and names already taken in the deploy dir,
+// are resolved according to the collision mode.
+func artifactDestinations(bundles []foundBundle, template, scheme, configuration, deployDir, collisionMode string) ([]string, error) {
+	taken := map[string]string{}
+	var destinations []string
+	for _, bundle := range bundles {
+		name, err := renderArtifactName(template, bundle, scheme, configuration)
+		if err != nil {
+			return nil, err
+		}
+
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		for i := 1; ; i++ {
+			destination := filepath.Join(deployDir, name)
+
+			other, takenInRun := taken[name]
+			_, err := os.Lstat(destination)
+			existing := err == nil
+			if !takenInRun && !existing {
+				break
+			}
+
+			reason := fmt.Sprintf("%s already exists in the deploy dir", name)
+			if takenInRun {
+				reason = fmt.Sprintf("both %s and %s are named %s", other, bundle.Path, name)
+			}
+
+			switch {
+			case collisionMode == nameCollisionSuffix:
+				name = fmt.Sprintf("%s-%d%s", base, i, ext)
+				continue
+			case collisionMode == nameCollisionOverwrite && !takenInRun:
+				log.Warnf("Overwriting artifact: %s", reason)
+			default:
+				return nil, fmt.Errorf("artifact name collision: %s", reason)
+			}
+			break
+		}
+
+		if name != filepath.Base(bundle.Path) {
+			log.Printf("Naming %s as %s", filepath.Base(bundle.Path), name)
+		}
+		taken[name] = bundle.Path
+		destinations = append(destinations, filepath.Join(deployDir, name))
+	}
+
+	return destinations, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appbundle"
+)
+
+func TestValidateArtifactNameTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  bool
+	}{
+		{template: "{name}"},
+		{template: "{scheme}-{configuration}-{platform}-{version}-{build_number}-{bundle_id}"},
+		{template: "static name"},
+		{template: "", wantErr: true},
+		{template: "  ", wantErr: true},
+		{template: "{name}-{commit}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			if err := validateArtifactNameTemplate(tt.template); (err != nil) != tt.wantErr {
+				t.Errorf("validateArtifactNameTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderArtifactName(t *testing.T) {
+	bundle := foundBundle{
+		Path: "/build/App.app",
+		Info: appbundle.Info{
+			BundleID:     "io.bitrise.App",
+			ShortVersion: "1.2.3",
+			BuildNumber:  "42",
+			PlatformName: "iphonesimulator",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		bundle   foundBundle
+		want     string
+		wantErr  bool
+	}{
+		{name: "bundle name", template: "{name}", bundle: bundle, want: "App.app"},
+		{name: "every placeholder", template: "{name}-{scheme}-{configuration}-{platform}-{version}-{build_number}-{bundle_id}", bundle: bundle, want: "App-Scheme-Release-iphonesimulator-1.2.3-42-io.bitrise.App.app"},
+		{name: "unsafe characters", template: "{scheme}/{version}:{name}", bundle: bundle, want: "Scheme-1.2.3-App.app"},
+		{name: "unknown Info.plist values", template: "{name}-{version}", bundle: foundBundle{Path: "/build/App.app"}, want: "App-.app"},
+		{name: "empty name", template: "{version}", bundle: foundBundle{Path: "/build/App.app"}, wantErr: true},
+		{name: "dot name", template: ".", bundle: bundle, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderArtifactName(tt.template, tt.bundle, "Scheme", "Release")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderArtifactName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("renderArtifactName() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArtifactDestinations(t *testing.T) {
+	bundles := []foundBundle{
+		{Path: "/build/Debug-iphonesimulator/App.app", Info: appbundle.Info{BundleID: "io.bitrise.App"}},
+		{Path: "/build/Release-iphonesimulator/App.app", Info: appbundle.Info{BundleID: "io.bitrise.App.beta"}},
+	}
+
+	tests := []struct {
+		name          string
+		template      string
+		collisionMode string
+		existing      []string
+		want          []string
+		wantErr       bool
+	}{
+		{
+			name:          "distinct names",
+			template:      "{bundle_id}",
+			collisionMode: nameCollisionFail,
+			want:          []string{"io.bitrise.App.app", "io.bitrise.App.beta.app"},
+		},
+		{
+			name:          "collision in the run fails",
+			template:      "{name}",
+			collisionMode: nameCollisionFail,
+			wantErr:       true,
+		},
+		{
+			name:          "collision in the run fails even when overwriting",
+			template:      "{name}",
+			collisionMode: nameCollisionOverwrite,
+			wantErr:       true,
+		},
+		{
+			name:          "collision in the run is suffixed",
+			template:      "{name}",
+			collisionMode: nameCollisionSuffix,
+			want:          []string{"App.app", "App-1.app"},
+		},
+		{
+			name:          "existing artifact is overwritten",
+			template:      "{bundle_id}",
+			collisionMode: nameCollisionOverwrite,
+			existing:      []string{"io.bitrise.App.app"},
+			want:          []string{"io.bitrise.App.app", "io.bitrise.App.beta.app"},
+		},
+		{
+			name:          "existing artifact fails",
+			template:      "{bundle_id}",
+			collisionMode: nameCollisionFail,
+			existing:      []string{"io.bitrise.App.app"},
+			wantErr:       true,
+		},
+		{
+			name:          "existing artifacts are suffixed",
+			template:      "{name}",
+			collisionMode: nameCollisionSuffix,
+			existing:      []string{"App.app", "App-1.app"},
+			want:          []string{"App-2.app", "App-3.app"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployDir := t.TempDir()
+			for _, name := range tt.existing {
+				if err := os.Mkdir(filepath.Join(deployDir, name), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			destinations, err := artifactDestinations(bundles, tt.template, "Scheme", "Release", deployDir, tt.collisionMode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("artifactDestinations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, destination := range destinations {
+				if filepath.Dir(destination) != deployDir {
+					t.Errorf("destination %s is not in the deploy dir", destination)
+				}
+				got = append(got, filepath.Base(destination))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("artifactDestinations() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
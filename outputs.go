@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// outputRecordFileName is the file in the output dir listing what the runs of the step wrote there.
+const outputRecordFileName = ".xcode-build-for-simulator-outputs.json"
+
+// outputRecord lists the paths the runs of the step wrote into the output dir,
+// so later runs can remove them before exporting their own artifacts.
+type outputRecord struct {
+	Runs []outputRun `json:"runs"`
+}
+
+// outputRun is the paths a run wrote into the output dir, relative to it.
+// A build (identified by BITRISE_BUILD_SLUG) may run the step several times, for example for different schemes,
+// so runs are keyed by the build and the scheme and configuration inputs.
+type outputRun struct {
+	BuildSlug     string   `json:"build_slug"`
+	Scheme        string   `json:"scheme"`
+	Configuration string   `json:"configuration"`
+	Paths         []string `json:"paths"`
+}
+
+// replaces tells whether the outputs of the other run are stale for this run:
+// they are written by a different build, or by an earlier run of the same step.
+// Runs of the same build for other schemes or configurations are kept, their outputs may already be exported.
+func (r outputRun) replaces(other outputRun) bool {
+	if r.BuildSlug != other.BuildSlug {
+		return true
+	}
+	return r.Scheme == other.Scheme && r.Configuration == other.Configuration
+}
+
+// namePrefix scopes the names of the run's logs and reports in the output dir, for example App-Debug,
+// so the runs of the same build for other schemes or configurations don't overwrite them.
+func (r outputRun) namePrefix() string {
+	if r.Configuration == "" {
+		return r.Scheme
+	}
+	return r.Scheme + "-" + r.Configuration
+}
+
+func readOutputRecord(outputDir string) (outputRecord, error) {
+	recordPth := filepath.Join(outputDir, outputRecordFileName)
+	content, err := os.ReadFile(recordPth)
+	if errors.Is(err, os.ErrNotExist) {
+		return outputRecord{}, nil
+	} else if err != nil {
+		return outputRecord{}, err
+	}
+
+	var record outputRecord
+	if err := json.Unmarshal(content, &record); err != nil {
+		log.Warnf("Ignoring invalid output record (%s): %s", recordPth, err)
+		return outputRecord{}, nil
+	}
+	return record, nil
+}
+
+func saveOutputRecord(outputDir string, record outputRecord) error {
+	recordPth := filepath.Join(outputDir, outputRecordFileName)
+	if len(record.Runs) == 0 {
+		if err := os.Remove(recordPth); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	content, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(recordPth, content, 0644)
+}
+
+// removePreviousOutputs removes the artifacts of the recorded runs replaced by the given run.
+// Paths pointing outside the output dir, or recorded by a run which is kept, are not removed.
+func removePreviousOutputs(outputDir string, run outputRun) error {
+	record, err := readOutputRecord(outputDir)
+	if err != nil {
+		return err
+	}
+
+	var kept []outputRun
+	keptPaths := map[string]bool{}
+	var stale []outputRun
+	for _, previous := range record.Runs {
+		if run.replaces(previous) {
+			stale = append(stale, previous)
+			continue
+		}
+		kept = append(kept, previous)
+		for _, rel := range previous.Paths {
+			keptPaths[rel] = true
+		}
+	}
+
+	for _, previous := range stale {
+		for _, rel := range previous.Paths {
+			pth := filepath.Join(outputDir, rel)
+			if rel == "" || filepath.IsAbs(rel) || !strings.HasPrefix(pth, outputDir+string(filepath.Separator)) {
+				log.Warnf("Ignoring output record entry outside of the output dir: %s", rel)
+				continue
+			}
+			if keptPaths[rel] {
+				continue
+			}
+
+			if _, err := os.Lstat(pth); err != nil {
+				continue
+			}
+			log.Printf("Removing artifact of a previous run: %s", rel)
+			if err := os.RemoveAll(pth); err != nil {
+				return fmt.Errorf("failed to remove %s: %s", pth, err)
+			}
+		}
+	}
+
+	return saveOutputRecord(outputDir, outputRecord{Runs: kept})
+}
+
+// writeOutputRecord records the given paths of the output dir which exist as the outputs of the run,
+// replacing the run's earlier record.
+func writeOutputRecord(outputDir string, run outputRun, pths []string) error {
+	run.Paths = []string{}
+	seen := map[string]bool{}
+	for _, pth := range pths {
+		if _, err := os.Lstat(pth); err != nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(outputDir, pth)
+		if err != nil || strings.HasPrefix(rel, "..") || seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		run.Paths = append(run.Paths, rel)
+	}
+
+	record, err := readOutputRecord(outputDir)
+	if err != nil {
+		return err
+	}
+
+	var runs []outputRun
+	for _, previous := range record.Runs {
+		if previous.BuildSlug == run.BuildSlug && previous.Scheme == run.Scheme && previous.Configuration == run.Configuration {
+			continue
+		}
+		runs = append(runs, previous)
+	}
+	record.Runs = append(runs, run)
+
+	return saveOutputRecord(outputDir, record)
+}
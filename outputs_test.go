@@ -0,0 +1,176 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRemovePreviousOutputs(t *testing.T) {
+	tests := []struct {
+		name        string
+		run         outputRun
+		wantKept    []string
+		wantRemoved []string
+		wantRuns    int
+	}{
+		{
+			name:        "next build removes every run",
+			run:         outputRun{BuildSlug: "build-2", Scheme: "App", Configuration: "Debug"},
+			wantRemoved: []string{"App.app", "App.app.zip", "build.log", "Widget.app"},
+		},
+		{
+			name:     "another scheme of the same build keeps the earlier runs",
+			run:      outputRun{BuildSlug: "build-1", Scheme: "Other", Configuration: "Debug"},
+			wantKept: []string{"App.app", "App.app.zip", "build.log", "Widget.app"},
+			wantRuns: 2,
+		},
+		{
+			name:        "same scheme and configuration of the same build replaces its run",
+			run:         outputRun{BuildSlug: "build-1", Scheme: "App", Configuration: "Debug"},
+			wantKept:    []string{"Widget.app", "build.log"},
+			wantRemoved: []string{"App.app", "App.app.zip"},
+			wantRuns:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, rel := range []string{"App.app", "App.app.zip", "build.log", "Widget.app", "unrelated.txt"} {
+				if err := os.WriteFile(filepath.Join(dir, rel), []byte(rel), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pths := func(rels ...string) []string {
+				var pths []string
+				for _, rel := range rels {
+					pths = append(pths, filepath.Join(dir, rel))
+				}
+				return pths
+			}
+			if err := writeOutputRecord(dir, outputRun{BuildSlug: "build-1", Scheme: "App", Configuration: "Debug"}, pths("App.app", "App.app.zip", "missing.zip")); err != nil {
+				t.Fatal(err)
+			}
+			if err := writeOutputRecord(dir, outputRun{BuildSlug: "build-1", Scheme: "Widget", Configuration: "Debug"}, pths("Widget.app", "build.log")); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := removePreviousOutputs(dir, tt.run); err != nil {
+				t.Fatalf("removePreviousOutputs() error = %v", err)
+			}
+
+			for _, rel := range append(tt.wantKept, "unrelated.txt") {
+				if _, err := os.Stat(filepath.Join(dir, rel)); err != nil {
+					t.Errorf("%s is removed, want it kept", rel)
+				}
+			}
+			for _, rel := range tt.wantRemoved {
+				if _, err := os.Stat(filepath.Join(dir, rel)); err == nil {
+					t.Errorf("%s is kept, want it removed", rel)
+				}
+			}
+
+			record, err := readOutputRecord(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(record.Runs) != tt.wantRuns {
+				t.Errorf("record has %d runs, want %d: %+v", len(record.Runs), tt.wantRuns, record.Runs)
+			}
+			if _, err := os.Stat(filepath.Join(dir, outputRecordFileName)); (err == nil) != (tt.wantRuns > 0) {
+				t.Errorf("record file exists: %v, want: %v", err == nil, tt.wantRuns > 0)
+			}
+		})
+	}
+}
+
+func TestRemovePreviousOutputsKeepsSharedPaths(t *testing.T) {
+	dir := t.TempDir()
+	logPth := filepath.Join(dir, "build.log")
+	if err := os.WriteFile(logPth, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	record := outputRecord{Runs: []outputRun{
+		{BuildSlug: "build-1", Scheme: "App", Paths: []string{"build.log", "../outside.txt", "/etc/passwd", ""}},
+		{BuildSlug: "build-2", Scheme: "Widget", Paths: []string{"build.log"}},
+	}}
+	if err := saveOutputRecord(dir, record); err != nil {
+		t.Fatal(err)
+	}
+	outsidePth := filepath.Join(filepath.Dir(dir), "outside.txt")
+	if err := os.WriteFile(outsidePth, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Remove(outsidePth)
+	}()
+
+	if err := removePreviousOutputs(dir, outputRun{BuildSlug: "build-2", Scheme: "App"}); err != nil {
+		t.Fatalf("removePreviousOutputs() error = %v", err)
+	}
+
+	for _, pth := range []string{logPth, outsidePth} {
+		if _, err := os.Stat(pth); err != nil {
+			t.Errorf("%s is removed, want it kept", pth)
+		}
+	}
+}
+
+func TestWriteOutputRecord(t *testing.T) {
+	dir := t.TempDir()
+	for _, rel := range []string{"App.app", "App.app.zip", "artifacts.json"} {
+		if err := os.WriteFile(filepath.Join(dir, rel), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := outputRun{BuildSlug: "build-1", Scheme: "App", Configuration: "Debug"}
+	if err := writeOutputRecord(dir, run, []string{filepath.Join(dir, "App.app")}); err != nil {
+		t.Fatal(err)
+	}
+	pths := []string{
+		filepath.Join(dir, "artifacts.json"),
+		filepath.Join(dir, "App.app"),
+		filepath.Join(dir, "App.app.zip"),
+		filepath.Join(dir, "App.app.zip"),
+		filepath.Join(dir, "missing.zip"),
+		filepath.Join(filepath.Dir(dir), "outside.txt"),
+	}
+	if err := writeOutputRecord(dir, run, pths); err != nil {
+		t.Fatalf("writeOutputRecord() error = %v", err)
+	}
+
+	record, err := readOutputRecord(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(record.Runs) != 1 {
+		t.Fatalf("record has %d runs, want the run's earlier record replaced: %+v", len(record.Runs), record.Runs)
+	}
+	got := record.Runs[0].Paths
+	sort.Strings(got)
+	if want := []string{"App.app", "App.app.zip", "artifacts.json"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded paths = %q, want %q", got, want)
+	}
+}
+
+func TestOutputRunNamePrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		run  outputRun
+		want string
+	}{
+		{name: "scheme and configuration", run: outputRun{BuildSlug: "build-1", Scheme: "App", Configuration: "Debug"}, want: "App-Debug"},
+		{name: "scheme's default configuration", run: outputRun{BuildSlug: "build-1", Scheme: "App"}, want: "App"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.run.namePrefix(); got != tt.want {
+				t.Errorf("namePrefix() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
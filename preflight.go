@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcscheme"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcworkspace"
+)
+
+const (
+	configurationValidationStrict = "strict"
+	configurationValidationWarn   = "warn"
+)
+
+// Preflight validates the inputs against the project before spending time on the build.
+func (b BuildForSimulatorStep) Preflight(cfg RunOpts) (RunOpts, error) {
+	fmt.Println()
+	log.Infof("Validating project inputs")
+
+	scheme, err := resolveScheme(cfg.ProjectPath, cfg.Scheme)
+	if err != nil {
+		return RunOpts{}, err
+	}
+	cfg.Scheme = scheme
+
+	if err := validateConfiguration(cfg.ProjectPath, cfg.Configuration, cfg.ConfigurationValidation); err != nil {
+		return RunOpts{}, err
+	}
+
+	return cfg, nil
+}
+
+// resolveScheme checks that the scheme exists in the project or workspace.
+// The shared, user and autocreated schemes are considered, the same ones Xcode shows.
+// If no scheme is given and there is exactly one app scheme, that scheme is returned.
+func resolveScheme(projectPth, scheme string) (string, error) {
+	schemes, err := projectSchemes(projectPth)
+	if err != nil {
+		if scheme == "" {
+			return "", fmt.Errorf("no scheme provided and failed to list the project's schemes: %s", err)
+		}
+		log.Warnf("Failed to list the project's schemes, skipping validation: %s", err)
+		return scheme, nil
+	}
+
+	var names, appSchemes []string
+	for _, s := range schemes {
+		names = append(names, s.Name)
+		if _, ok := s.AppBuildActionEntry(); ok {
+			appSchemes = append(appSchemes, s.Name)
+		}
+	}
+
+	if scheme == "" {
+		if len(appSchemes) == 1 {
+			log.Donef("No scheme provided, using the only app scheme: %s", appSchemes[0])
+			return appSchemes[0], nil
+		}
+		return "", fmt.Errorf("no scheme provided and the project has %d app schemes, please set the `scheme` input, available schemes: %s", len(appSchemes), strings.Join(names, ", "))
+	}
+
+	if len(schemes) == 0 {
+		log.Warnf("No scheme found in the project, skipping scheme validation")
+		return scheme, nil
+	}
+
+	if slices.Contains(names, scheme) {
+		log.Donef("Scheme (%s) found", scheme)
+		return scheme, nil
+	}
+
+	message := fmt.Sprintf("scheme (%s) not found in the project", scheme)
+	if suggestions := similarNames(scheme, names, 3); len(suggestions) > 0 {
+		message += fmt.Sprintf(", did you mean: %s?", strings.Join(suggestions, ", "))
+	}
+	return "", fmt.Errorf("%s (available schemes: %s)", message, strings.Join(names, ", "))
+}
+
+// projectSchemes returns the schemes of a project, or of a workspace and all of its member projects.
+// If a scheme name appears more than once, the first one wins (workspace schemes come first).
+func projectSchemes(projectPth string) ([]xcscheme.Scheme, error) {
+	if !xcworkspace.IsWorkspace(projectPth) {
+		project, err := xcodeproj.Open(projectPth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open project (%s): %s", projectPth, err)
+		}
+		return project.Schemes()
+	}
+
+	workspace, err := xcworkspace.Open(projectPth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workspace (%s): %s", projectPth, err)
+	}
+	schemesByContainer, err := workspace.Schemes()
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []string
+	for container := range schemesByContainer {
+		if container != workspace.Path {
+			containers = append(containers, container)
+		}
+	}
+	slices.Sort(containers)
+	containers = append([]string{workspace.Path}, containers...)
+
+	var schemes []xcscheme.Scheme
+	seen := map[string]bool{}
+	for _, container := range containers {
+		for _, scheme := range schemesByContainer[container] {
+			if seen[scheme.Name] {
+				continue
+			}
+			seen[scheme.Name] = true
+			schemes = append(schemes, scheme)
+		}
+	}
+
+	return schemes, nil
+}
+
+// similarNames returns at most n candidates that are close to name by edit distance, the closest first.
+func similarNames(name string, candidates []string, n int) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+
+	var matches []match
+	lowerName := strings.ToLower(name)
+	for _, candidate := range candidates {
+		lowerCandidate := strings.ToLower(candidate)
+		distance := levenshtein(lowerName, lowerCandidate)
+
+		maxDistance := max(2, len([]rune(candidate))/3)
+		if distance <= maxDistance || strings.Contains(lowerCandidate, lowerName) || strings.Contains(lowerName, lowerCandidate) {
+			matches = append(matches, match{name: candidate, distance: distance})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return a.distance - b.distance
+	})
+
+	var names []string
+	for i := 0; i < len(matches) && i < n; i++ {
+		names = append(names, matches[i].name)
+	}
+	return names
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func validateConfiguration(projectPth, configuration, validation string) error {
+	if configuration == "" {
+		return nil
+	}
+
+	configurations, err := projectConfigurations(projectPth)
+	if err != nil {
+		log.Warnf("Failed to read the project's build configurations, skipping validation: %s", err)
+		return nil
+	}
+
+	if slices.Contains(configurations, configuration) {
+		log.Donef("Build configuration (%s) found", configuration)
+		return nil
+	}
+
+	message := fmt.Sprintf("build configuration (%s) not found in the project, valid configurations: %s", configuration, strings.Join(configurations, ", "))
+	if validation == configurationValidationStrict {
+		return fmt.Errorf("%s", message)
+	}
+
+	log.Warnf("The %s, xcodebuild will fall back to the Scheme's configuration", message)
+	return nil
+}
+
+func projectConfigurations(projectPth string) ([]string, error) {
+	projects := []string{projectPth}
+	if xcworkspace.IsWorkspace(projectPth) {
+		workspace, err := xcworkspace.Open(projectPth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open workspace (%s): %s", projectPth, err)
+		}
+		if projects, err = workspace.ProjectFileLocations(); err != nil {
+			return nil, fmt.Errorf("failed to list the projects of the workspace (%s): %s", projectPth, err)
+		}
+	}
+
+	var configurations []string
+	for _, projectPth := range projects {
+		project, err := xcodeproj.Open(projectPth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open project (%s): %s", projectPth, err)
+		}
+
+		for _, buildConfiguration := range project.Proj.BuildConfigurationList.BuildConfigurations {
+			if !slices.Contains(configurations, buildConfiguration.Name) {
+				configurations = append(configurations, buildConfiguration.Name)
+			}
+		}
+	}
+	slices.Sort(configurations)
+
+	return configurations, nil
+}
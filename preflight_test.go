@@ -0,0 +1,342 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTestProject creates a minimal .xcodeproj with the given project level build configurations,
+// and an application target for each of the given target names.
+func writeTestProject(t *testing.T, pth string, configurations, appTargets []string) {
+	t.Helper()
+
+	var configurationIDs, targetIDs, objects []string
+	for i, configuration := range configurations {
+		id := fmt.Sprintf("C%023d", i)
+		configurationIDs = append(configurationIDs, id+",")
+		objects = append(objects, fmt.Sprintf(`		%s = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				SDKROOT = iphoneos;
+			};
+			name = "%s";
+		};`, id, configuration))
+	}
+	for i, target := range appTargets {
+		id := fmt.Sprintf("T%023d", i)
+		productID := fmt.Sprintf("R%023d", i)
+		targetIDs = append(targetIDs, id+",")
+		objects = append(objects, fmt.Sprintf(`		%s = {
+			isa = PBXNativeTarget;
+			buildConfigurationList = L00000000000000000000000;
+			buildPhases = (
+			);
+			dependencies = (
+			);
+			name = "%s";
+			productReference = %s;
+			productType = "com.apple.product-type.application";
+		};
+		%s = {
+			isa = PBXFileReference;
+			explicitFileType = wrapper.application;
+			path = "%s.app";
+			sourceTree = BUILT_PRODUCTS_DIR;
+		};`, id, target, productID, productID, target))
+	}
+
+	content := fmt.Sprintf(`// !$*UTF8*$!
+{
+	archiveVersion = 1;
+	classes = {
+	};
+	objectVersion = 56;
+	objects = {
+		P00000000000000000000000 = {
+			isa = PBXProject;
+			attributes = {
+				TargetAttributes = {
+				};
+			};
+			buildConfigurationList = L00000000000000000000000;
+			targets = (
+				%s
+			);
+		};
+		L00000000000000000000000 = {
+			isa = XCConfigurationList;
+			buildConfigurations = (
+				%s
+			);
+			defaultConfigurationName = Release;
+		};
+%s
+	};
+	rootObject = P00000000000000000000000;
+}
+`, strings.Join(targetIDs, "\n\t\t\t\t"), strings.Join(configurationIDs, "\n\t\t\t\t"), strings.Join(objects, "\n"))
+
+	if err := os.MkdirAll(pth, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pth, "project.pbxproj"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTestScheme creates a scheme in the given schemes dir (xcshareddata/xcschemes or xcuserdata/<user>.xcuserdatad/xcschemes),
+// building the given product.
+func writeTestScheme(t *testing.T, schemesDir, name, buildableName string) {
+	t.Helper()
+
+	content := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Scheme
+   LastUpgradeVersion = "1500"
+   version = "1.7">
+   <BuildAction
+      parallelizeBuildables = "YES"
+      buildImplicitDependencies = "YES">
+      <BuildActionEntries>
+         <BuildActionEntry
+            buildForTesting = "YES"
+            buildForRunning = "YES"
+            buildForProfiling = "YES"
+            buildForArchiving = "YES"
+            buildForAnalyzing = "YES">
+            <BuildableReference
+               BuildableIdentifier = "primary"
+               BlueprintIdentifier = "T00000000000000000000000"
+               BuildableName = "%s"
+               BlueprintName = "%s"
+               ReferencedContainer = "container:App.xcodeproj">
+            </BuildableReference>
+         </BuildActionEntry>
+      </BuildActionEntries>
+   </BuildAction>
+</Scheme>
+`, buildableName, name)
+
+	if err := os.MkdirAll(schemesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(schemesDir, name+".xcscheme"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTestWorkspace creates an .xcworkspace with the given contents.xcworkspacedata items.
+func writeTestWorkspace(t *testing.T, pth, items string) {
+	t.Helper()
+
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<Workspace
+   version = "1.0">
+` + items + `
+</Workspace>
+`
+	if err := os.MkdirAll(pth, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pth, "contents.xcworkspacedata"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProjectConfigurations(t *testing.T) {
+	dir := t.TempDir()
+	writeTestProject(t, filepath.Join(dir, "App.xcodeproj"), []string{"Debug", "Release", "Staging Release"}, nil)
+	writeTestProject(t, filepath.Join(dir, "Modules", "Core.xcodeproj"), []string{"Debug", "Release", "Profile"}, nil)
+	writeTestProject(t, filepath.Join(dir, "Pods", "Pods.xcodeproj"), []string{"Debug", "Release"}, nil)
+	writeTestWorkspace(t, filepath.Join(dir, "App.xcworkspace"), `   <FileRef
+      location = "group:App.xcodeproj">
+   </FileRef>
+   <Group
+      location = "container:Modules"
+      name = "Modules">
+      <FileRef
+         location = "group:Core.xcodeproj">
+      </FileRef>
+   </Group>
+   <FileRef
+      location = "group:Pods/Pods.xcodeproj">
+   </FileRef>
+   <FileRef
+      location = "group:README.md">
+   </FileRef>`)
+
+	tests := []struct {
+		name    string
+		project string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "project",
+			project: filepath.Join(dir, "App.xcodeproj"),
+			want:    []string{"Debug", "Release", "Staging Release"},
+		},
+		{
+			name:    "workspace with nested projects",
+			project: filepath.Join(dir, "App.xcworkspace"),
+			want:    []string{"Debug", "Profile", "Release", "Staging Release"},
+		},
+		{
+			name:    "missing project",
+			project: filepath.Join(dir, "Missing.xcodeproj"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := projectConfigurations(tt.project)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("projectConfigurations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("projectConfigurations() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConfiguration(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "App.xcodeproj")
+	writeTestProject(t, project, []string{"Debug", "Release"}, nil)
+
+	tests := []struct {
+		name          string
+		configuration string
+		validation    string
+		wantErr       bool
+	}{
+		{name: "not set", configuration: "", validation: configurationValidationStrict},
+		{name: "found", configuration: "Release", validation: configurationValidationStrict},
+		{name: "not found, warn", configuration: "Beta", validation: configurationValidationWarn},
+		{name: "not found, strict", configuration: "Beta", validation: configurationValidationStrict, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfiguration(project, tt.configuration, tt.validation)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateConfiguration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveScheme(t *testing.T) {
+	currentUser, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userSchemesDir := func(container string) string {
+		return filepath.Join(container, "xcuserdata", currentUser.Username+".xcuserdatad", "xcschemes")
+	}
+
+	dir := t.TempDir()
+
+	// shared and user schemes
+	project := filepath.Join(dir, "App.xcodeproj")
+	writeTestProject(t, project, []string{"Debug"}, []string{"App"})
+	writeTestScheme(t, filepath.Join(project, "xcshareddata", "xcschemes"), "App", "App.app")
+	writeTestScheme(t, filepath.Join(project, "xcshareddata", "xcschemes"), "Core", "Core.framework")
+	writeTestScheme(t, userSchemesDir(project), "App Staging", "App.app")
+
+	// autocreated schemes
+	autocreated := filepath.Join(dir, "Autocreated.xcodeproj")
+	writeTestProject(t, autocreated, []string{"Debug"}, []string{"Autocreated"})
+
+	// workspace scheme and a member project
+	workspace := filepath.Join(dir, "App.xcworkspace")
+	writeTestWorkspace(t, workspace, `   <FileRef
+      location = "group:App.xcodeproj">
+   </FileRef>`)
+	writeTestScheme(t, filepath.Join(workspace, "xcshareddata", "xcschemes"), "Workspace Tests", "AppTests.xctest")
+
+	tests := []struct {
+		name    string
+		project string
+		scheme  string
+		want    string
+		wantErr string
+	}{
+		{name: "shared scheme", project: project, scheme: "App", want: "App"},
+		{name: "user scheme", project: project, scheme: "App Staging", want: "App Staging"},
+		{name: "autocreated scheme", project: autocreated, scheme: "Autocreated", want: "Autocreated"},
+		{name: "only autocreated app scheme is picked", project: autocreated, scheme: "", want: "Autocreated"},
+		{name: "workspace scheme", project: workspace, scheme: "Workspace Tests", want: "Workspace Tests"},
+		{name: "member project scheme", project: workspace, scheme: "App Staging", want: "App Staging"},
+		{name: "not found with suggestion", project: project, scheme: "Ap", wantErr: "did you mean: App"},
+		{name: "several app schemes", project: project, scheme: "", wantErr: "has 2 app schemes"},
+		{name: "unreadable project keeps the scheme", project: filepath.Join(dir, "Missing.xcodeproj"), scheme: "App", want: "App"},
+		{name: "unreadable project without scheme", project: filepath.Join(dir, "Missing.xcodeproj"), scheme: "", wantErr: "no scheme provided"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveScheme(tt.project, tt.scheme)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveScheme() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveScheme() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveScheme() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimilarNames(t *testing.T) {
+	candidates := []string{"App", "App-Staging", "AppTests", "Widget", "Core"}
+
+	tests := []struct {
+		name string
+		n    int
+		want []string
+	}{
+		{name: "app", n: 3, want: []string{"App", "AppTests", "App-Staging"}},
+		{name: "Wigdet", n: 3, want: []string{"Widget"}},
+		{name: "staging", n: 3, want: []string{"App-Staging"}},
+		{name: "Cor", n: 1, want: []string{"Core"}},
+		{name: "Unrelated", n: 3, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := similarNames(tt.name, candidates, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("similarNames(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "", b: "abc", want: 3},
+		{a: "abc", b: "", want: 3},
+		{a: "scheme", b: "scheme", want: 0},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "flaw", b: "lawn", want: 2},
+		{a: "Wigdet", b: "Widget", want: 2},
+		{a: "äpp", b: "app", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if got := levenshtein(tt.a, tt.b); got != tt.want {
+				t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/go-steputils/tools"
+	"github.com/bitrise-io/go-utils/log"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appsize"
+)
+
+const appSizeReportFileName = "app_size_report.json"
+
+// reportAppSizes writes the size breakdown of the exported bundles and checks it against the budget, if any.
+// The report is exported even if the budget is exceeded.
+func reportAppSizes(artifacts []Artifact, reportPth string, budget *appsize.Budget, baseline *appsize.Report) error {
+	report, err := measureAppSizes(artifacts)
+	if err != nil {
+		return err
+	}
+
+	if err := report.WriteTable(os.Stdout); err != nil {
+		log.Warnf("Failed to print app size report: %s", err)
+	}
+
+	if err := report.WriteJSON(reportPth); err != nil {
+		return fmt.Errorf("failed to write app size report: %s", err)
+	}
+	if err := tools.ExportEnvironmentWithEnvman(bitriseAppSizeReportEnvKey, reportPth); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", bitriseAppSizeReportEnvKey, err)
+	}
+	log.Donef("%s -> %s", bitriseAppSizeReportEnvKey, reportPth)
+
+	if budget == nil {
+		return nil
+	}
+	return checkAppSizeBudget(report, *budget, baseline)
+}
+
+// measureAppSizes breaks down the size of the exported bundles, keyed by their bundle identifier.
+func measureAppSizes(artifacts []Artifact) (appsize.Report, error) {
+	report := appsize.Report{}
+	for _, artifact := range artifacts {
+		size, err := appsize.Measure(artifact.Path, artifact.Info.Executable)
+		if err != nil {
+			return appsize.Report{}, err
+		}
+		size.BundleID = artifact.Info.BundleID
+		report.Bundles = append(report.Bundles, size)
+	}
+	return report, nil
+}
+
+// checkAppSizeBudget returns an error listing the budget violations of the report.
+func checkAppSizeBudget(report appsize.Report, budget appsize.Budget, baseline *appsize.Report) error {
+	for _, bundleID := range budget.Unmatched(report) {
+		log.Warnf("Budget rule of bundle %s matches none of the exported bundles' identifiers", bundleID)
+	}
+
+	if baseline != nil {
+		for _, previous := range report.Unmatched(*baseline) {
+			name := previous.Name
+			if previous.BundleID != "" {
+				name = fmt.Sprintf("%s (%s)", previous.Name, previous.BundleID)
+			}
+			log.Warnf("Baseline bundle %s matches none of the exported bundles, its growth is not checked", name)
+		}
+	}
+
+	violations := budget.Check(report, baseline)
+	if len(violations) == 0 {
+		log.Donef("The app sizes are within the budget")
+		return nil
+	}
+
+	var lines []string
+	for _, violation := range violations {
+		log.Errorf("- %s", violation)
+		lines = append(lines, violation.String())
+	}
+	return fmt.Errorf("%d app size budget violation(s): %s", len(violations), strings.Join(lines, "; "))
+}
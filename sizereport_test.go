@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appbundle"
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appsize"
+)
+
+func TestMeasureAppSizes(t *testing.T) {
+	dir := t.TempDir()
+	appPth := filepath.Join(dir, "App-Debug.app")
+	writeTestBundle(t, appPth, nil)
+	if err := os.WriteFile(filepath.Join(appPth, "App"), make([]byte, 100), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := measureAppSizes([]Artifact{{Path: appPth, Info: appbundle.Info{BundleID: "io.bitrise.App", Executable: "App"}}})
+	if err != nil {
+		t.Fatalf("measureAppSizes() error = %v", err)
+	}
+	if len(report.Bundles) != 1 {
+		t.Fatalf("report has %d bundles, want 1", len(report.Bundles))
+	}
+	bundle := report.Bundles[0]
+	if bundle.Name != "App-Debug.app" || bundle.BundleID != "io.bitrise.App" {
+		t.Errorf("bundle = %s (%s), want App-Debug.app (io.bitrise.App)", bundle.Name, bundle.BundleID)
+	}
+	if got := bundle.Components[appsize.ComponentExecutable]; got != 100 {
+		t.Errorf("executable size = %d, want 100", got)
+	}
+}
+
+func TestCheckAppSizeBudget(t *testing.T) {
+	growth := func(percent float64) *float64 {
+		return &percent
+	}
+	bundle := func(name string, executable, plugins int64) appsize.BundleSize {
+		return appsize.BundleSize{
+			Name:       name,
+			BundleID:   "io.bitrise.App",
+			Total:      executable + plugins,
+			Components: map[appsize.Component]int64{appsize.ComponentExecutable: executable, appsize.ComponentPlugIns: plugins},
+		}
+	}
+	report := appsize.Report{Bundles: []appsize.BundleSize{bundle("App-Debug.app", 100, 50)}}
+
+	tests := []struct {
+		name     string
+		budget   appsize.Budget
+		baseline *appsize.Report
+		wantErr  bool
+	}{
+		{
+			name: "bundle rule is matched by bundle id, not by the templated name",
+			budget: appsize.Budget{
+				Rule:    appsize.Rule{Limits: map[string]int64{"executable": 10}},
+				Bundles: map[string]appsize.Rule{"io.bitrise.App": {Limits: map[string]int64{"executable": 1000}}},
+			},
+		},
+		{
+			name: "rule keyed by the bundle name does not apply",
+			budget: appsize.Budget{
+				Rule:    appsize.Rule{Limits: map[string]int64{"executable": 10}},
+				Bundles: map[string]appsize.Rule{"App-Debug.app": {Limits: map[string]int64{"executable": 1000}}},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "growth within the limit",
+			budget:   appsize.Budget{Rule: appsize.Rule{MaxGrowthPercent: growth(10)}},
+			baseline: &appsize.Report{Bundles: []appsize.BundleSize{bundle("App.app", 100, 50)}},
+		},
+		{
+			name:     "component new to the baseline",
+			budget:   appsize.Budget{Rule: appsize.Rule{MaxGrowthPercent: growth(10)}},
+			baseline: &appsize.Report{Bundles: []appsize.BundleSize{bundle("App.app", 100, 0)}},
+			wantErr:  true,
+		},
+		{
+			name:     "baseline without a matching bundle",
+			budget:   appsize.Budget{Rule: appsize.Rule{MaxGrowthPercent: growth(0)}},
+			baseline: &appsize.Report{Bundles: []appsize.BundleSize{{Name: "Other.app", BundleID: "io.bitrise.Other"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkAppSizeBudget(report, tt.budget, tt.baseline); (err != nil) != tt.wantErr {
+				t.Errorf("checkAppSizeBudget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
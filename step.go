@@ -1,12 +1,15 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/bitrise-io/go-steputils/output"
 	"github.com/bitrise-io/go-steputils/stepconf"
@@ -17,39 +20,101 @@ import (
 	"github.com/bitrise-io/go-utils/stringutil"
 	"github.com/bitrise-io/go-utils/v2/fileutil"
 	v2pathutil "github.com/bitrise-io/go-utils/v2/pathutil"
-	"github.com/bitrise-io/go-utils/ziputil"
 	"github.com/bitrise-io/go-xcode/v2/xcconfig"
 	"github.com/bitrise-io/go-xcode/xcodebuild"
 	"github.com/bitrise-io/go-xcode/xcpretty"
 	"github.com/kballard/go-shellquote"
 
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appbundle"
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/appsize"
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/buildlog"
 	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/util"
 )
 
 const (
-	xcodebuilgLogFileName      = "xcodebuild_build.log"
-	bitriseAppDirPathKey       = "BITRISE_APP_DIR_PATH"
-	bitriseAppDirPathListKey   = "BITRISE_APP_DIR_PATH_LIST"
-	bitriseXcodebuildLogEnvKey = "BITRISE_XCODEBUILD_BUILD_FOR_SIMULATOR_LOG_PATH"
+	xcodebuilgLogFileName               = "xcodebuild_build.log"
+	xcodebuildIssuesReportFileName      = "xcodebuild_build_issues.json"
+	xcresultBundleExtension             = ".xcresult"
+	buildSettingsFileName               = "xcodebuild_build_settings.json"
+	xcarchiveNameSuffix                 = "-simulator.xcarchive"
+	bitriseAppDirPathKey                = "BITRISE_APP_DIR_PATH"
+	bitriseAppDirPathListKey            = "BITRISE_APP_DIR_PATH_LIST"
+	bitriseAppDirPathListJSONKey        = "BITRISE_APP_DIR_PATH_LIST_JSON"
+	bitriseXcodebuildLogEnvKey          = "BITRISE_XCODEBUILD_BUILD_FOR_SIMULATOR_LOG_PATH"
+	bitriseXcodeRawResultTextEnvKey     = "BITRISE_XCODE_BUILD_RAW_RESULT_TEXT_PATH"
+	bitriseXcodebuildIssuesReportEnvKey = "BITRISE_XCODEBUILD_BUILD_ISSUES_REPORT_PATH"
+	bitriseXCResultPathEnvKey           = "BITRISE_XCRESULT_PATH"
+	bitriseXCResultZipPathEnvKey        = "BITRISE_XCRESULT_ZIP_PATH"
+	bitriseXctestrunFilePathEnvKey      = "BITRISE_XCTESTRUN_FILE_PATH"
+	bitriseTestDirPathEnvKey            = "BITRISE_TEST_DIR_PATH"
+	bitriseAppArtifactManifestEnvKey    = "BITRISE_APP_ARTIFACT_MANIFEST_PATH"
+	bitriseAppPackagePathListEnvKey     = "BITRISE_APP_PACKAGE_PATH_LIST"
+	bitriseAppPackagePathListJSONEnvKey = "BITRISE_APP_PACKAGE_PATH_LIST_JSON"
+	bitriseDSYMDirPathEnvKey            = "BITRISE_DSYM_DIR_PATH"
+	bitriseDSYMPathEnvKey               = "BITRISE_DSYM_PATH"
+	bitriseXCArchiveZipPathEnvKey       = "BITRISE_XCARCHIVE_ZIP_PATH"
+	bitriseAppSizeReportEnvKey          = "BITRISE_APP_SIZE_REPORT_PATH"
+	bitriseAppetizePackagePathEnvKey    = "BITRISE_APPETIZE_PACKAGE_PATH"
+	bitriseAppetizePublicKeyEnvKey      = "BITRISE_APPETIZE_PUBLIC_KEY"
+	bitriseAppetizeAppURLEnvKey         = "BITRISE_APPETIZE_APP_URL"
+
+	buildAction           = "build"
+	archiveAction         = "archive"
+	buildForTestingAction = "build-for-testing"
+
+	packageFormatZip   = "zip"
+	packageFormatTarGz = "tar.gz"
+
+	maxPrintedBuildErrors = 10
+	maxArtifactWorkers    = 4
+	buildLogTailSize      = 64 * 1024
 )
 
 type Config struct {
 	ProjectPath string `env:"project_path,required"`
-	Scheme      string `env:"scheme,required"`
+	Scheme      string `env:"scheme"`
 	Destination string `env:"destination,required"`
 
 	// xcodebuild configuration
 	Configuration               string `env:"configuration"`
+	ConfigurationValidation     string `env:"configuration_validation,opt[warn,strict]"`
 	XCConfigContent             string `env:"xcconfig_content"`
+	XcodebuildAction            string `env:"xcodebuild_action,opt[build,archive,build-for-testing]"`
 	PerformCleanAction          bool   `env:"perform_clean_action,opt[yes,no]"`
+	CleanMode                   string `env:"clean_mode,opt[xcodebuild,derived_data,build_folder]"`
 	XcodebuildAdditionalOptions string `env:"xcodebuild_options"`
 	LogFormatter                string `env:"log_formatter,opt[xcpretty,xcodebuild]"`
 
 	// Output export
-	OutputDir string `env:"output_dir,required"`
+	OutputDir              string `env:"output_dir,required"`
+	GenerateXCResultBundle bool   `env:"generate_xcresult_bundle,opt[yes,no]"`
+	ArtifactPackaging      string `env:"artifact_packaging,opt[zip,tar.gz,both,none]"`
+	IncludeBundleNames     string `env:"include_bundle_names"`
+	ExcludeBundleNames     string `env:"exclude_bundle_names"`
+	IncludeBundleIDs       string `env:"include_bundle_ids"`
+	ExcludeBundleIDs       string `env:"exclude_bundle_ids"`
+	EmbeddedAppMode        string `env:"embedded_app_mode,opt[top_level,top_level_and_embedded,embedded_only]"`
+	ExportDSYMs            bool   `env:"export_dsyms,opt[yes,no]"`
+	ExportXCArchive        bool   `env:"export_xcarchive,opt[yes,no]"`
+	ArtifactNameTemplate   string `env:"artifact_name_template,required"`
+	ArtifactNameCollision  string `env:"artifact_name_collision,opt[overwrite,fail,suffix]"`
+	AppSizeBudgetPath      string `env:"app_size_budget_path"`
+	AppSizeBaselinePath    string `env:"app_size_baseline_path"`
+	ArchitectureCheck      string `env:"architecture_check,opt[off,warn,fail]"`
+	SimulatorArchitectures string `env:"simulator_architectures,required"`
+	DylibCheck             string `env:"dylib_check,opt[off,warn,fail]"`
+	InstallabilityCheck    string `env:"installability_check,opt[off,warn,fail]"`
+
+	// Appetize
+	AppetizeUpload    bool            `env:"appetize_upload,opt[yes,no]"`
+	AppetizeAPIToken  stepconf.Secret `env:"appetize_api_token"`
+	AppetizePublicKey string          `env:"appetize_public_key"`
+	AppetizeBaseURL   string          `env:"appetize_base_url"`
 
 	// Debugging
 	VerboseLog bool `env:"verbose_log,required"`
+
+	BuildSlug string `env:"BITRISE_BUILD_SLUG"`
 }
 
 type RunOpts struct {
@@ -58,14 +123,38 @@ type RunOpts struct {
 	Destination string
 
 	Configuration               string
+	ConfigurationValidation     string
 	XCConfigContent             string
+	XcodebuildAction            string
 	PerformCleanAction          bool
+	CleanMode                   string
 	XcodebuildAdditionalOptions []string
 	LogFormatter                string
 
-	OutputDir string
+	OutputDir              string
+	GenerateXCResultBundle bool
+	PackageFormats         []string
+	BundleFilter           bundleFilter
+	EmbeddedAppMode        string
+	ExportDSYMs            bool
+	ExportXCArchive        bool
+	ArtifactNameTemplate   string
+	ArtifactNameCollision  string
+	AppSizeBudget          *appsize.Budget
+	AppSizeBaseline        *appsize.Report
+	ArchitectureCheck      string
+	RequiredArchitectures  []string
+	DylibCheck             string
+	InstallabilityCheck    string
+
+	AppetizeUpload    bool
+	AppetizeAPIToken  string
+	AppetizePublicKey string
+	AppetizeBaseURL   string
 
 	CacheLevel string
+
+	BuildSlug string
 }
 
 type BuildForSimulatorStep struct {
@@ -109,21 +198,91 @@ func (b BuildForSimulatorStep) ProcessConfig() (RunOpts, error) {
 		return RunOpts{}, fmt.Errorf("`-xcconfig` option found in `xcodebuild_options`, please clear `xcconfig_content` input as can not set both")
 	}
 
+	filter, err := newBundleFilter(config.IncludeBundleNames, config.ExcludeBundleNames, config.IncludeBundleIDs, config.ExcludeBundleIDs)
+	if err != nil {
+		return RunOpts{}, err
+	}
+
+	if err := validateArtifactNameTemplate(config.ArtifactNameTemplate); err != nil {
+		return RunOpts{}, fmt.Errorf("invalid `artifact_name_template` (%s): %s", config.ArtifactNameTemplate, err)
+	}
+
+	// The budget and the baseline are read upfront, so the baseline can be a previous report in the output dir.
+	var sizeBudget *appsize.Budget
+	if config.AppSizeBudgetPath != "" {
+		budget, err := appsize.ReadBudget(config.AppSizeBudgetPath)
+		if err != nil {
+			return RunOpts{}, fmt.Errorf("invalid `app_size_budget_path` (%s): %s", config.AppSizeBudgetPath, err)
+		}
+		sizeBudget = &budget
+	}
+	var sizeBaseline *appsize.Report
+	if config.AppSizeBaselinePath != "" {
+		baseline, err := appsize.ReadReport(config.AppSizeBaselinePath)
+		if err != nil {
+			return RunOpts{}, fmt.Errorf("invalid `app_size_baseline_path` (%s): %s", config.AppSizeBaselinePath, err)
+		}
+		sizeBaseline = &baseline
+	}
+
+	requiredArchs := requiredSimulatorArchitectures(config.SimulatorArchitectures, hostArchitecture())
+	if len(requiredArchs) == 0 {
+		return RunOpts{}, fmt.Errorf("no architecture set in `simulator_architectures`")
+	}
+
+	if config.AppetizeUpload && config.AppetizeAPIToken == "" {
+		return RunOpts{}, fmt.Errorf("`appetize_api_token` is required if `appetize_upload` is set to yes")
+	}
+
 	return RunOpts{
 		ProjectPath: config.ProjectPath,
 		Scheme:      config.Scheme,
 		Destination: config.Destination,
 
 		Configuration:               config.Configuration,
+		ConfigurationValidation:     config.ConfigurationValidation,
 		XCConfigContent:             config.XCConfigContent,
+		XcodebuildAction:            config.XcodebuildAction,
 		PerformCleanAction:          config.PerformCleanAction,
+		CleanMode:                   config.CleanMode,
 		XcodebuildAdditionalOptions: additionalOptions,
 		LogFormatter:                config.LogFormatter,
 
-		OutputDir: config.OutputDir,
+		OutputDir:              config.OutputDir,
+		GenerateXCResultBundle: config.GenerateXCResultBundle,
+		PackageFormats:         packageFormats(config.ArtifactPackaging),
+		BundleFilter:           filter,
+		EmbeddedAppMode:        config.EmbeddedAppMode,
+		ExportDSYMs:            config.ExportDSYMs,
+		ExportXCArchive:        config.ExportXCArchive,
+		ArtifactNameTemplate:   config.ArtifactNameTemplate,
+		ArtifactNameCollision:  config.ArtifactNameCollision,
+		AppSizeBudget:          sizeBudget,
+		AppSizeBaseline:        sizeBaseline,
+		ArchitectureCheck:      config.ArchitectureCheck,
+		RequiredArchitectures:  requiredArchs,
+		DylibCheck:             config.DylibCheck,
+		InstallabilityCheck:    config.InstallabilityCheck,
+
+		AppetizeUpload:    config.AppetizeUpload,
+		AppetizeAPIToken:  string(config.AppetizeAPIToken),
+		AppetizePublicKey: strings.TrimSpace(config.AppetizePublicKey),
+		AppetizeBaseURL:   config.AppetizeBaseURL,
+
+		BuildSlug: config.BuildSlug,
 	}, nil
 }
 
+func packageFormats(packaging string) []string {
+	switch packaging {
+	case "both":
+		return []string{packageFormatZip, packageFormatTarGz}
+	case "none":
+		return nil
+	}
+	return []string{packaging}
+}
+
 func (b BuildForSimulatorStep) InstallDependencies(cfg RunOpts) (RunOpts, error) {
 	if cfg.LogFormatter != "xcpretty" {
 		return cfg, nil
@@ -194,72 +353,149 @@ func (s BuildForSimulatorStep) Run(cfg RunOpts) (ExportOptions, error) {
 		}
 	}
 
-	// Output files
-	rawXcodebuildOutputLogPath := filepath.Join(absOutputDir, xcodebuilgLogFileName)
+	outputRun := outputRun{BuildSlug: cfg.BuildSlug, Scheme: cfg.Scheme, Configuration: cfg.Configuration}
+
+	// Output files, named after the scheme and configuration (for example App-Debug-xcodebuild_build.log)
+	namePrefix := outputRun.namePrefix()
+	rawXcodebuildOutputLogPath := filepath.Join(absOutputDir, namePrefix+"-"+xcodebuilgLogFileName)
+	buildIssuesReportPath := filepath.Join(absOutputDir, namePrefix+"-"+xcodebuildIssuesReportFileName)
+	xcresultPath := filepath.Join(absOutputDir, namePrefix+xcresultBundleExtension)
+	buildSettingsPath := filepath.Join(absOutputDir, namePrefix+"-"+buildSettingsFileName)
+	artifactManifestPath := filepath.Join(absOutputDir, namePrefix+"-"+artifactManifestFileName)
+	dsymDirPath := filepath.Join(absOutputDir, namePrefix+dSYMsNameSuffix)
+	xcarchiveZipPath := filepath.Join(absOutputDir, namePrefix+xcarchiveNameSuffix+".zip")
+	appSizeReportPath := filepath.Join(absOutputDir, namePrefix+"-"+appSizeReportFileName)
 
 	//
 	// Cleanup
-	{
-		filesToCleanup := []string{
-			rawXcodebuildOutputLogPath,
-		}
-
-		for _, pth := range filesToCleanup {
-			if err := os.RemoveAll(pth); err != nil {
-				return ExportOptions{}, fmt.Errorf("failed to remove path (%s), error: %s", pth, err)
-			}
+	if err := removePreviousOutputs(absOutputDir, outputRun); err != nil {
+		return ExportOptions{}, fmt.Errorf("failed to remove the artifacts of the previous run: %s", err)
+	}
 
+	// The run's own outputs are removed even if they are not recorded, for example if the record was lost.
+	for _, pth := range []string{
+		rawXcodebuildOutputLogPath,
+		buildIssuesReportPath,
+		xcresultPath,
+		xcresultPath + ".zip",
+		buildSettingsPath,
+		artifactManifestPath,
+		dsymDirPath,
+		dsymDirPath + ".zip",
+		xcarchiveZipPath,
+		appSizeReportPath,
+	} {
+		if err := os.RemoveAll(pth); err != nil {
+			return ExportOptions{}, fmt.Errorf("failed to remove path (%s), error: %s", pth, err)
 		}
 	}
 
+	// The outputs written by this run are recorded in the output dir, so the next build can remove them.
+	var writtenOutputs []string
+	defer func() {
+		if err := writeOutputRecord(absOutputDir, outputRun, writtenOutputs); err != nil {
+			log.Warnf("Failed to record the artifacts of this run: %s", err)
+		}
+	}()
+
 	absProjectPath, err := filepath.Abs(cfg.ProjectPath)
 	if err != nil {
 		return ExportOptions{}, fmt.Errorf("failed to get absolute project path: %s", err)
 	}
 
-	tmpDir, err := pathutil.NormalizedOSTempDirPath("xcodeArchive")
-	if err != nil {
-		return ExportOptions{}, fmt.Errorf("failed to create temp dir, error: %s", err)
+	archivePth := ""
+	if cfg.XcodebuildAction == archiveAction {
+		tmpDir, err := pathutil.NormalizedOSTempDirPath("xcodeArchive")
+		if err != nil {
+			return ExportOptions{}, fmt.Errorf("failed to create temp dir, error: %s", err)
+		}
+		archivePth = filepath.Join(tmpDir, cfg.Scheme+xcarchiveNameSuffix)
+	}
+
+	xcconfigPath := ""
+	if cfg.XCConfigContent != "" {
+		xcconfigPath, err = s.XCConfigWriter.Write(cfg.XCConfigContent)
+		if err != nil {
+			return ExportOptions{}, fmt.Errorf("failed to write xcconfig file contents: %w", err)
+		}
+	}
+
+	settingsProvider := newBuildSettingsProvider(absProjectPath, cfg, xcconfigPath)
+
+	{
+		fmt.Println()
+		log.Infof("Resolving build settings")
+
+		if settings, err := settingsProvider.get(); err != nil {
+			log.Warnf("Failed to read build settings: %s", err)
+		} else {
+			writtenOutputs = append(writtenOutputs, buildSettingsPath)
+			if err := exportBuildSettings(settings, buildSettingsPath); err != nil {
+				log.Warnf("Failed to export build settings: %s", err)
+			}
+		}
+	}
+
+	if cfg.PerformCleanAction && cfg.CleanMode != cleanModeXcodebuild {
+		fmt.Println()
+		log.Infof("Cleaning build (%s)", cfg.CleanMode)
+
+		if err := cleanBuildDir(cfg.CleanMode, settingsProvider, cfg.XcodebuildAdditionalOptions); err != nil {
+			return ExportOptions{}, fmt.Errorf("clean failed: %s", err)
+		}
 	}
-	archivePth := filepath.Join(tmpDir, cfg.Scheme+"-simulator.xcarchive")
+
 	{
 		fmt.Println()
 		log.Infof("Running build")
 
-		actions := []string{"archive"}
-		if cfg.PerformCleanAction {
+		var actions []string
+		if cfg.PerformCleanAction && cfg.CleanMode == cleanModeXcodebuild {
 			actions = append(actions, "clean")
 		}
+		actions = append(actions, cfg.XcodebuildAction)
 
-		archiveCmd := xcodebuild.NewCommandBuilder(absProjectPath, actions...)
-		archiveCmd.SetArchivePath(archivePth)
-		archiveCmd.SetScheme(cfg.Scheme)
+		buildCmd := xcodebuild.NewCommandBuilder(absProjectPath, actions...)
+		if archivePth != "" {
+			buildCmd.SetArchivePath(archivePth)
+		}
+		buildCmd.SetScheme(cfg.Scheme)
 		if cfg.Configuration != "" {
-			archiveCmd.SetConfiguration(cfg.Configuration)
+			buildCmd.SetConfiguration(cfg.Configuration)
 		}
-		archiveCmd.SetDestination(cfg.Destination)
-		archiveCmd.SetCustomOptions(cfg.XcodebuildAdditionalOptions)
-		if cfg.XCConfigContent != "" {
-			xcconfigPath, err := s.XCConfigWriter.Write(cfg.XCConfigContent)
-			if err != nil {
-				return ExportOptions{}, fmt.Errorf("failed to write xcconfig file contents: %w", err)
-			}
-			archiveCmd.SetXCConfigPath(xcconfigPath)
+		buildCmd.SetDestination(cfg.Destination)
+		buildCmd.SetCustomOptions(cfg.XcodebuildAdditionalOptions)
+		if xcconfigPath != "" {
+			buildCmd.SetXCConfigPath(xcconfigPath)
+		}
+		if cfg.GenerateXCResultBundle {
+			buildCmd.SetResultBundlePath(xcresultPath)
 		}
 
-		rawXcodeBuildOut, err := runCommand(archiveCmd, cfg.LogFormatter == "xcpretty")
+		logCapture, err := buildlog.NewCapture(rawXcodebuildOutputLogPath, buildLogTailSize)
 		if err != nil {
-			if cfg.LogFormatter == "xcpretty" {
-				log.Errorf("\nLast lines of the Xcode's build log:")
-				fmt.Println(stringutil.LastNLines(rawXcodeBuildOut, 10))
-
-				if err := output.ExportOutputFileContent(rawXcodeBuildOut, rawXcodebuildOutputLogPath, bitriseXcodebuildLogEnvKey); err != nil {
-					log.Warnf("Failed to export %s, error: %s", bitriseXcodebuildLogEnvKey, err)
-				} else {
-					log.Warnf(`You can find the last couple of lines of Xcode's build log above, but the full log is also available in the %s.ß
+			return ExportOptions{}, fmt.Errorf("failed to create xcodebuild log file (%s): %s", rawXcodebuildOutputLogPath, err)
+		}
+		writtenOutputs = append(writtenOutputs, rawXcodebuildOutputLogPath, buildIssuesReportPath)
+
+		err = runCommand(buildCmd, cfg.LogFormatter == "xcpretty", logCapture)
+		if closeErr := logCapture.Close(); closeErr != nil {
+			log.Warnf("Failed to close xcodebuild log file: %s", closeErr)
+		}
+		logExported := exportRawXcodebuildLog(rawXcodebuildOutputLogPath)
+		issuesReport := logCapture.Report()
+		exportBuildIssuesReport(issuesReport, buildIssuesReportPath)
+		if cfg.GenerateXCResultBundle {
+			exportXCResultBundle(xcresultPath)
+			writtenOutputs = append(writtenOutputs, xcresultPath, xcresultPath+".zip")
+		}
+		if err != nil {
+			printBuildErrors(issuesReport, logCapture.Tail())
+
+			if logExported {
+				log.Warnf(`You can find the errors of Xcode's build log above, but the full log is also available in the %s.
 The log file is stored in $BITRISE_DEPLOY_DIR, and its full path is available in the %s environment variable
-(value: %s)`, xcodebuilgLogFileName, bitriseXcodebuildLogEnvKey, rawXcodebuildOutputLogPath)
-				}
+(value: %s)`, filepath.Base(rawXcodebuildOutputLogPath), bitriseXcodebuildLogEnvKey, rawXcodebuildOutputLogPath)
 			}
 			return ExportOptions{}, fmt.Errorf("build failed, error: %s", err)
 		}
@@ -269,20 +505,222 @@ The log file is stored in $BITRISE_DEPLOY_DIR, and its full path is available in
 	fmt.Println()
 	log.Infof("Copy artifacts to $BITRISE_DEPLOY_DIR")
 
-	exportedArtifacts, err := copyArtifactsToDeployDir(archivePth, absOutputDir)
+	// archive collects the products into the xcarchive,
+	// build and build-for-testing leave them in the configuration's build dir (e.g. Debug-iphonesimulator).
+	// The build dir may hold the stale products of other schemes too (for example in a shared DerivedData),
+	// so only the scheme's app and the bundles embedded in it are exported from there.
+	artifactsSourceDir := archivePth
+	appSearchPth := archivePth
+	var testOutputs testBundle
+	if cfg.XcodebuildAction != archiveAction {
+		buildSettings, err := settingsProvider.get()
+		if err != nil {
+			return ExportOptions{}, fmt.Errorf("failed to read build settings: %s", err)
+		}
+
+		artifactsSourceDir, err = buildSettings.String("CONFIGURATION_BUILD_DIR")
+		if err != nil {
+			return ExportOptions{}, fmt.Errorf("failed to read configuration build dir: %s", err)
+		}
+		appSearchPth, err = schemeProductPath(buildSettings)
+		if err != nil {
+			return ExportOptions{}, fmt.Errorf("failed to read the scheme's product: %s", err)
+		}
+
+		if cfg.XcodebuildAction == buildForTestingAction {
+			testOutputs, err = copyTestBundleToOutputDir(buildSettings, cfg.Scheme, absOutputDir)
+			if err != nil {
+				return ExportOptions{}, fmt.Errorf("export test bundle: %s", err)
+			}
+			writtenOutputs = append(writtenOutputs, testOutputs.XctestrunPath, testOutputs.TestDirPath)
+		}
+	}
+
+	foundBundles, err := findAppBundles(appSearchPth)
 	if err != nil {
 		return ExportOptions{}, fmt.Errorf("export artifacts: %s", err)
 	}
 
-	return ExportOptions{
-		Artifacts: exportedArtifacts,
-		OutputDir: absOutputDir,
-	}, nil
+	appBundles, err := selectAppBundles(foundBundles, cfg.EmbeddedAppMode)
+	if err != nil {
+		return ExportOptions{}, fmt.Errorf("export artifacts: %s", err)
+	}
+
+	appBundles, err = filterAppBundles(appBundles, cfg.BundleFilter)
+	if err != nil {
+		return ExportOptions{}, fmt.Errorf("export artifacts: %s", err)
+	}
+
+	productName := ""
+	configuration := cfg.Configuration
+	if settings, err := settingsProvider.get(); err == nil {
+		productName, _ = settings.String("PRODUCT_NAME")
+		if configuration == "" {
+			configuration, _ = settings.String("CONFIGURATION")
+		}
+	}
+	mainAppPth := mainAppPath(foundBundles, archivePth, productName)
+	appBundles = orderAppBundles(appBundles, mainAppPth, productName)
+
+	destinations, err := artifactDestinations(appBundles, cfg.ArtifactNameTemplate, cfg.Scheme, configuration, absOutputDir, cfg.ArtifactNameCollision)
+	if err != nil {
+		return ExportOptions{}, fmt.Errorf("export artifacts: %s", err)
+	}
+
+	for _, destination := range destinations {
+		writtenOutputs = append(writtenOutputs, destination)
+		for _, format := range cfg.PackageFormats {
+			writtenOutputs = append(writtenOutputs, destination+"."+format)
+		}
+	}
+
+	exportedArtifacts, err := copyArtifactsToDeployDir(appBundles, destinations, cfg.PackageFormats)
+	if err != nil {
+		return ExportOptions{}, fmt.Errorf("export artifacts: %s", err)
+	}
+
+	hosts := embeddedAppHosts(foundBundles)
+	for i, bundle := range appBundles {
+		host, ok := hosts[bundle.Path]
+		exportedArtifacts[i].Role = appRole(bundle.Path, mainAppPth, ok)
+		if !ok {
+			continue
+		}
+		exportedArtifacts[i].Host = filepath.Base(host)
+		if hostIdx := slices.IndexFunc(appBundles, func(b foundBundle) bool { return b.Path == host }); hostIdx >= 0 {
+			exportedArtifacts[i].HostPath = exportedArtifacts[hostIdx].Path
+		}
+	}
+
+	fmt.Println()
+	log.Infof("App size report")
+
+	writtenOutputs = append(writtenOutputs, appSizeReportPath)
+	if err := reportAppSizes(exportedArtifacts, appSizeReportPath, cfg.AppSizeBudget, cfg.AppSizeBaseline); err != nil {
+		return ExportOptions{}, fmt.Errorf("app size report: %s", err)
+	}
+
+	if cfg.ArchitectureCheck != binaryCheckOff {
+		fmt.Println()
+		log.Infof("Checking binary architectures")
+
+		if err := checkArchitectures(exportedArtifacts, cfg.RequiredArchitectures, cfg.ArchitectureCheck); err != nil {
+			return ExportOptions{}, fmt.Errorf("architecture check: %s", err)
+		}
+	}
+
+	if cfg.DylibCheck != binaryCheckOff {
+		fmt.Println()
+		log.Infof("Checking linked libraries")
+
+		if err := checkDylibs(exportedArtifacts, cfg.DylibCheck); err != nil {
+			return ExportOptions{}, fmt.Errorf("library check: %s", err)
+		}
+	}
+
+	if cfg.InstallabilityCheck != binaryCheckOff {
+		fmt.Println()
+		log.Infof("Checking simulator installability")
+
+		if err := checkInstallability(exportedArtifacts, cfg.InstallabilityCheck); err != nil {
+			return ExportOptions{}, fmt.Errorf("installability check: %s", err)
+		}
+	}
+
+	exportOpts := ExportOptions{
+		Artifacts:     exportedArtifacts,
+		XctestrunPath: testOutputs.XctestrunPath,
+		TestDirPath:   testOutputs.TestDirPath,
+	}
+
+	if len(exportedArtifacts) > 0 {
+		writtenOutputs = append(writtenOutputs, artifactManifestPath)
+		if err := writeArtifactManifest(exportedArtifacts, artifactManifestPath); err != nil {
+			return ExportOptions{}, fmt.Errorf("failed to write artifact manifest: %s", err)
+		}
+		exportOpts.ArtifactManifestPath = artifactManifestPath
+	}
+
+	if cfg.ExportDSYMs {
+		dsyms, err := findDSYMs(archivePth, artifactsSourceDir, appSearchPth)
+		if err != nil {
+			return ExportOptions{}, fmt.Errorf("export dSYMs: %s", err)
+		}
+		if len(dsyms) == 0 {
+			log.Warnf("No dSYM found, check if the DEBUG_INFORMATION_FORMAT build setting is set to dwarf-with-dsym")
+		} else {
+			writtenOutputs = append(writtenOutputs, dsymDirPath, dsymDirPath+".zip")
+		}
+
+		exportOpts.DSYMDirPath, exportOpts.DSYMZipPath, err = exportDSYMs(dsyms, dsymDirPath)
+		if err != nil {
+			return ExportOptions{}, fmt.Errorf("export dSYMs: %s", err)
+		}
+	}
+
+	if cfg.ExportXCArchive {
+		if archivePth == "" {
+			log.Warnf("The xcarchive is only generated by the %s action, skipping its export", archiveAction)
+		} else {
+			writtenOutputs = append(writtenOutputs, xcarchiveZipPath)
+			if err := exportXCArchive(archivePth, xcarchiveZipPath); err != nil {
+				return ExportOptions{}, fmt.Errorf("export xcarchive: %s", err)
+			}
+			exportOpts.XCArchiveZipPath = xcarchiveZipPath
+		}
+	}
+
+	if cfg.AppetizeUpload {
+		fmt.Println()
+		log.Infof("Uploading the main app to Appetize")
+
+		mainArtifact, err := appetizeArtifact(exportedArtifacts)
+		if err != nil {
+			return ExportOptions{}, fmt.Errorf("appetize upload: %s", err)
+		}
+		app, packagePth, err := uploadToAppetize(mainArtifact, absOutputDir, cfg.AppetizeBaseURL, cfg.AppetizeAPIToken, cfg.AppetizePublicKey)
+		if packagePth != "" {
+			writtenOutputs = append(writtenOutputs, packagePth)
+		}
+		if err != nil {
+			return ExportOptions{}, fmt.Errorf("appetize upload: %s", err)
+		}
+		exportOpts.AppetizePackagePath = packagePth
+		exportOpts.AppetizePublicKey = app.PublicKey
+		exportOpts.AppetizeAppURL = app.AppURL
+	}
+
+	return exportOpts, nil
+}
+
+// Artifact is an exported app bundle and the packages (zip, tar.gz) created next to it.
+// Host is the name of the app the bundle is embedded in, HostPath is set if the host is exported too.
+type Artifact struct {
+	Path     string
+	Packages []string
+	Host     string
+	HostPath string
+	// Role tells how the app relates to the scheme's host app, see appRole.
+	Role string
+
+	// Info is the Info.plist of the bundle, read when the bundle was found in the build products.
+	Info    appbundle.Info
+	InfoErr error
 }
 
 type ExportOptions struct {
-	Artifacts []string
-	OutputDir string
+	Artifacts            []Artifact
+	ArtifactManifestPath string
+	XctestrunPath        string
+	TestDirPath          string
+
+	DSYMDirPath      string
+	DSYMZipPath      string
+	XCArchiveZipPath string
+
+	AppetizePackagePath string
+	AppetizePublicKey   string
+	AppetizeAppURL      string
 }
 
 func (b BuildForSimulatorStep) ExportOutput(options ExportOptions) error {
@@ -299,68 +737,235 @@ func (b BuildForSimulatorStep) ExportOutput(options ExportOptions) error {
 		log.Donef("%s -> %s", bitriseAppDirPathKey, mainTargetAppPath)
 		log.Donef("%s -> %s", bitriseAppDirPathListKey, pathMap)
 
+		appList, err := exportAppListJSON(options.Artifacts)
+		if err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", bitriseAppDirPathListJSONKey, err)
+		}
+		log.Donef("%s -> %s", bitriseAppDirPathListJSONKey, appList)
+
+		if err := tools.ExportEnvironmentWithEnvman(bitriseAppArtifactManifestEnvKey, options.ArtifactManifestPath); err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", bitriseAppArtifactManifestEnvKey, err)
+		}
+		log.Donef("%s -> %s", bitriseAppArtifactManifestEnvKey, options.ArtifactManifestPath)
+
+		if err := exportPackageOutputs(options.Artifacts); err != nil {
+			return fmt.Errorf("failed to export package outputs: %s", err)
+		}
+
 		fmt.Println()
 	}
+
+	if options.XctestrunPath != "" {
+		for _, env := range []struct{ key, value string }{
+			{bitriseXctestrunFilePathEnvKey, options.XctestrunPath},
+			{bitriseTestDirPathEnvKey, options.TestDirPath},
+		} {
+			key, value := env.key, env.value
+			if err := tools.ExportEnvironmentWithEnvman(key, value); err != nil {
+				return fmt.Errorf("failed to export %s, error: %s", key, err)
+			}
+			log.Donef("%s -> %s", key, value)
+		}
+	}
+
+	for _, env := range []struct{ key, value string }{
+		{bitriseDSYMDirPathEnvKey, options.DSYMDirPath},
+		{bitriseDSYMPathEnvKey, options.DSYMZipPath},
+		{bitriseXCArchiveZipPathEnvKey, options.XCArchiveZipPath},
+		{bitriseAppetizePackagePathEnvKey, options.AppetizePackagePath},
+		{bitriseAppetizePublicKeyEnvKey, options.AppetizePublicKey},
+		{bitriseAppetizeAppURLEnvKey, options.AppetizeAppURL},
+	} {
+		if env.value == "" {
+			continue
+		}
+		if err := tools.ExportEnvironmentWithEnvman(env.key, env.value); err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", env.key, err)
+		}
+		log.Donef("%s -> %s", env.key, env.value)
+	}
 	return nil
 }
 
 // Ancillary Methods
 
-func exportOutput(artifacts []string) (string, string, error) {
-	mainAppArtifact := artifacts[0]
+func exportOutput(artifacts []Artifact) (string, string, error) {
+	mainAppArtifact := artifacts[0].Path
 	if err := tools.ExportEnvironmentWithEnvman(bitriseAppDirPathKey, mainAppArtifact); err != nil {
 		return "", "", err
 	}
 
-	pathMap := strings.Join(artifacts, "|")
+	var paths []string
+	for _, artifact := range artifacts {
+		paths = append(paths, artifact.Path)
+	}
+	pathMap := strings.Join(paths, "|")
 	pathMap = strings.Trim(pathMap, "|")
 
 	if err := tools.ExportEnvironmentWithEnvman(bitriseAppDirPathListKey, pathMap); err != nil {
 		return "", "", err
 	}
-	return artifacts[0], pathMap, nil
+	return mainAppArtifact, pathMap, nil
 }
 
-func copyArtifactsToDeployDir(archivePath string, deployDir string) ([]string, error) {
-	var copiedArtifacts []string
+func exportPackageOutputs(artifacts []Artifact) error {
+	packages := []string{}
+	for _, artifact := range artifacts {
+		packages = append(packages, artifact.Packages...)
+	}
+	if len(packages) == 0 {
+		return nil
+	}
 
-	if err := filepath.WalkDir(archivePath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+	packageList := strings.Join(packages, "|")
+	if err := tools.ExportEnvironmentWithEnvman(bitriseAppPackagePathListEnvKey, packageList); err != nil {
+		return err
+	}
+	log.Donef("%s -> %s", bitriseAppPackagePathListEnvKey, packageList)
 
-		if d.IsDir() && filepath.Ext(d.Name()) == ".app" {
-			destination := filepath.Join(deployDir, d.Name())
+	packageListJSON, err := json.Marshal(packages)
+	if err != nil {
+		return err
+	}
+	if err := tools.ExportEnvironmentWithEnvman(bitriseAppPackagePathListJSONEnvKey, string(packageListJSON)); err != nil {
+		return err
+	}
+	log.Donef("%s -> %s", bitriseAppPackagePathListJSONEnvKey, packageListJSON)
 
-			cmd := util.CopyDir(path, destination)
-			cmd.SetStdout(os.Stdout)
-			cmd.SetStderr(os.Stderr)
-			log.Debugf("$ " + cmd.PrintableCommandArgs())
-			if err := cmd.Run(); err != nil {
-				log.Debugf("failed to copy the generated app from (%s) to the Deploy dir", path)
-				return err
-			}
-			log.Donef("Copy: $BITRISE_DEPLOY_DIR/%s", d.Name())
+	return nil
+}
+
+func exportRawXcodebuildLog(logPth string) bool {
+	if err := output.ExportOutputFile(logPth, logPth, bitriseXcodebuildLogEnvKey); err != nil {
+		log.Warnf("Failed to export %s, error: %s", bitriseXcodebuildLogEnvKey, err)
+		return false
+	}
+	if err := tools.ExportEnvironmentWithEnvman(bitriseXcodeRawResultTextEnvKey, logPth); err != nil {
+		log.Warnf("Failed to export %s, error: %s", bitriseXcodeRawResultTextEnvKey, err)
+	}
 
-			copiedArtifacts = append(copiedArtifacts, destination)
+	return true
+}
 
-			err = ziputil.ZipDir(destination, destination+".zip", false)
-			if err != nil {
-				log.Errorf("Failed to zip %s: %s", destination, err)
-			}
-			log.Donef("Zip: $BITRISE_DEPLOY_DIR/%s.zip", d.Name())
-		}
+func exportBuildIssuesReport(report buildlog.Report, reportPth string) {
+	if err := report.WriteJSON(reportPth); err != nil {
+		log.Warnf("Failed to write the build issues report: %s", err)
+		return
+	}
+	if err := tools.ExportEnvironmentWithEnvman(bitriseXcodebuildIssuesReportEnvKey, reportPth); err != nil {
+		log.Warnf("Failed to export %s, error: %s", bitriseXcodebuildIssuesReportEnvKey, err)
+	}
+}
 
-		return nil
-	}); err != nil {
-		return nil, fmt.Errorf("failed to walk through the archive path: %s", err)
+func exportXCResultBundle(xcresultPth string) {
+	if exist, err := pathutil.IsDirExists(xcresultPth); err != nil {
+		log.Warnf("Failed to check if the xcresult bundle exists: %s", err)
+		return
+	} else if !exist {
+		log.Warnf("xcodebuild did not generate the xcresult bundle (%s)", xcresultPth)
+		return
 	}
 
-	if len(copiedArtifacts) != 0 {
-		log.Debugf("Success\n")
+	if err := tools.ExportEnvironmentWithEnvman(bitriseXCResultPathEnvKey, xcresultPth); err != nil {
+		log.Warnf("Failed to export %s, error: %s", bitriseXCResultPathEnvKey, err)
 	} else {
-		return nil, fmt.Errorf("didn't find any app artifacts")
+		log.Donef("%s -> %s", bitriseXCResultPathEnvKey, xcresultPth)
 	}
 
+	zipPth := xcresultPth + ".zip"
+	if err := util.ZipDir(xcresultPth, zipPth); err != nil {
+		log.Warnf("Failed to zip the xcresult bundle: %s", err)
+		return
+	}
+	if err := tools.ExportEnvironmentWithEnvman(bitriseXCResultZipPathEnvKey, zipPth); err != nil {
+		log.Warnf("Failed to export %s, error: %s", bitriseXCResultZipPathEnvKey, err)
+	} else {
+		log.Donef("%s -> %s", bitriseXCResultZipPathEnvKey, zipPth)
+	}
+}
+
+func printBuildErrors(report buildlog.Report, rawXcodebuildOutputTail string) {
+	firstErrors := report.FirstErrors(maxPrintedBuildErrors)
+	if len(firstErrors) == 0 {
+		log.Errorf("\nLast lines of the Xcode's build log:")
+		fmt.Println(stringutil.LastNLines(rawXcodebuildOutputTail, 10))
+		return
+	}
+
+	log.Errorf("\nErrors found in the Xcode's build log (showing %d of %d):", len(firstErrors), len(report.Errors))
+	for _, issue := range firstErrors {
+		fmt.Println(issue.String())
+	}
+}
+
+// copyArtifactsToDeployDir copies and packages the bundles to their destinations concurrently.
+// The returned artifacts keep the order of the bundles.
+func copyArtifactsToDeployDir(bundles []foundBundle, destinations []string, packageFormats []string) ([]Artifact, error) {
+	copiedArtifacts := make([]Artifact, len(bundles))
+	errs := make([]error, len(bundles))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(len(bundles), maxArtifactWorkers, runtime.NumCPU()); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				copiedArtifacts[i], errs[i] = copyAndPackageArtifact(bundles[i], destinations[i], packageFormats)
+			}
+		}()
+	}
+	for i := range bundles {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	log.Debugf("Success\n")
+
 	return copiedArtifacts, nil
 }
+
+func copyAndPackageArtifact(bundle foundBundle, destination string, packageFormats []string) (Artifact, error) {
+	name := filepath.Base(destination)
+
+	// Copying into an existing bundle would merge the two bundles.
+	if _, err := os.Lstat(destination); err == nil {
+		log.Printf("Removing existing %s", name)
+		if err := os.RemoveAll(destination); err != nil {
+			return Artifact{}, fmt.Errorf("failed to remove existing artifact (%s): %s", destination, err)
+		}
+	}
+
+	if err := util.CopyDir(bundle.Path, destination); err != nil {
+		return Artifact{}, fmt.Errorf("failed to copy the generated app from (%s) to the Deploy dir: %s", bundle.Path, err)
+	}
+	log.Donef("Copy: $BITRISE_DEPLOY_DIR/%s", name)
+
+	artifact := Artifact{Path: destination, Info: bundle.Info, InfoErr: bundle.InfoErr}
+	for _, format := range packageFormats {
+		packagePth := destination + "." + format
+
+		var err error
+		switch format {
+		case packageFormatZip:
+			err = util.ZipDir(destination, packagePth)
+		case packageFormatTarGz:
+			err = util.TarGzDir(destination, packagePth)
+		default:
+			err = fmt.Errorf("unknown package format")
+		}
+		if err != nil {
+			return Artifact{}, fmt.Errorf("failed to package %s as %s: %s", destination, format, err)
+		}
+		log.Donef("Package: $BITRISE_DEPLOY_DIR/%s", filepath.Base(packagePth))
+
+		artifact.Packages = append(artifact.Packages, packagePth)
+	}
+
+	return artifact, nil
+}
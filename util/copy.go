@@ -0,0 +1,132 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// CopyDir copies the source dir to the destination path, like `cp -R` would into a non-existing destination.
+// Symlinks are copied as symlinks, and file permissions (including the executable bits) and modification times are kept.
+// The destination must not exist, so the copy is never merged into (or nested in) an earlier one.
+func CopyDir(source string, destination string) error {
+	if _, err := os.Lstat(destination); err == nil {
+		return fmt.Errorf("destination already exists: %s", destination)
+	}
+
+	err := filepath.WalkDir(source, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(source, pth)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destination, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			// Owner write permission is needed to copy the content, the original mode is set in copyDirMode
+			if err := os.MkdirAll(target, info.Mode().Perm()|0200); err != nil {
+				return err
+			}
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			return copySymlink(pth, target)
+		case d.Type().IsRegular():
+			return CopyFile(pth, target)
+		}
+
+		return fmt.Errorf("unsupported file type (%s): %s", info.Mode().Type(), pth)
+	})
+	if err != nil {
+		return err
+	}
+
+	return copyDirModes(source, destination)
+}
+
+// CopyFile copies a regular file, keeping its permissions and modification time.
+func CopyFile(source string, destination string) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	if err := removeIfExists(destination); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	// The file was created with the umask applied
+	if err := os.Chmod(destination, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(destination, info.ModTime(), info.ModTime())
+}
+
+func copySymlink(source string, destination string) error {
+	linkTarget, err := os.Readlink(source)
+	if err != nil {
+		return err
+	}
+
+	if err := removeIfExists(destination); err != nil {
+		return err
+	}
+	return os.Symlink(linkTarget, destination)
+}
+
+// copyDirModes sets the destination dirs' permissions once all of their content is copied,
+// so read-only source dirs don't block the copy.
+func copyDirModes(source string, destination string) error {
+	return filepath.WalkDir(source, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(source, pth)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return os.Chmod(filepath.Join(destination, rel), info.Mode().Perm())
+	})
+}
+
+func removeIfExists(pth string) error {
+	if err := os.Remove(pth); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopyDir(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	source := writeTestBundle(t, t.TempDir(), modTime)
+	// A read-only dir must not block copying its content.
+	readOnly := filepath.Join(source, "Frameworks", "Core.framework")
+	if err := os.Chmod(readOnly, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chmod(readOnly, 0755)
+	})
+
+	destination := filepath.Join(t.TempDir(), "Copy.app")
+	if err := CopyDir(source, destination); err != nil {
+		t.Fatalf("CopyDir() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chmod(filepath.Join(destination, "Frameworks", "Core.framework"), 0755)
+	})
+
+	tests := []struct {
+		pth      string
+		mode     os.FileMode
+		content  string
+		linkDest string
+	}{
+		{pth: "App", mode: 0755, content: "App"},
+		{pth: "Info.plist", mode: 0644, content: "Info.plist"},
+		{pth: filepath.Join("Frameworks", "Core.framework"), mode: os.ModeDir | 0555},
+		{pth: filepath.Join("Frameworks", "Core.framework", "Core"), mode: 0755, content: "Frameworks/Core.framework/Core"},
+		{pth: filepath.Join("Frameworks", "Current"), mode: os.ModeSymlink, linkDest: "Core.framework/Core"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pth, func(t *testing.T) {
+			pth := filepath.Join(destination, tt.pth)
+			info, err := os.Lstat(pth)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.mode&os.ModeSymlink != 0 {
+				if info.Mode()&os.ModeSymlink == 0 {
+					t.Fatalf("mode = %s, want a symlink", info.Mode())
+				}
+				if linkDest, err := os.Readlink(pth); err != nil || linkDest != tt.linkDest {
+					t.Errorf("link = %s (%v), want %s", linkDest, err, tt.linkDest)
+				}
+				return
+			}
+
+			if got := info.Mode() & (os.ModeDir | os.ModePerm); got != tt.mode {
+				t.Errorf("mode = %s, want %s", got, tt.mode)
+			}
+			if info.IsDir() {
+				return
+			}
+			if !info.ModTime().Equal(modTime) {
+				t.Errorf("modification time = %s, want %s", info.ModTime(), modTime)
+			}
+			if content, err := os.ReadFile(pth); err != nil || string(content) != tt.content {
+				t.Errorf("content = %q (%v), want %q", content, err, tt.content)
+			}
+		})
+	}
+
+	if err := CopyDir(source, destination); err == nil {
+		t.Errorf("CopyDir() error = nil for an existing destination, want an error")
+	}
+}
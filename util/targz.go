@@ -0,0 +1,106 @@
+package util
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TarGzDir archives the source dir (the dir itself is the root entry of the archive) into a gzip compressed tarball.
+// Like ZipDir, it keeps symlinks and permissions, and writes a reproducible archive.
+func TarGzDir(sourceDir string, destinationTarGz string) error {
+	tmpTarGz := destinationTarGz + ".tmp"
+	if err := writeTarGz(sourceDir, tmpTarGz); err != nil {
+		_ = os.Remove(tmpTarGz)
+		return err
+	}
+
+	return os.Rename(tmpTarGz, destinationTarGz)
+}
+
+func writeTarGz(sourceDir string, destinationTarGz string) error {
+	file, err := os.Create(destinationTarGz)
+	if err != nil {
+		return err
+	}
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	baseDir := filepath.Dir(sourceDir)
+
+	walkErr := filepath.WalkDir(sourceDir, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(baseDir, pth)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		return addTarEntry(tarWriter, pth, filepath.ToSlash(rel), info)
+	})
+
+	tarCloseErr := tarWriter.Close()
+	gzipCloseErr := gzipWriter.Close()
+	fileCloseErr := file.Close()
+
+	for _, err := range []error{walkErr, tarCloseErr, gzipCloseErr, fileCloseErr} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addTarEntry(writer *tar.Writer, pth, name string, info fs.FileInfo) error {
+	linkTarget := ""
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(pth)
+		if err != nil {
+			return err
+		}
+		linkTarget = target
+	}
+
+	header, err := tar.FileInfoHeader(info, linkTarget)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	header.ModTime = archiveModTime
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.Uid, header.Gid = 0, 0
+	header.Uname, header.Gname = "", ""
+	header.Format = tar.FormatPAX
+
+	if err := writer.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	file, err := os.Open(pth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	_, err = io.Copy(writer, file)
+	return err
+}
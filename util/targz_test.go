@@ -0,0 +1,102 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTarGzDir(t *testing.T) {
+	first := filepath.Join(t.TempDir(), "first.tar.gz")
+	if err := TarGzDir(writeTestBundle(t, t.TempDir(), time.Now()), first); err != nil {
+		t.Fatalf("TarGzDir() error = %v", err)
+	}
+	second := filepath.Join(t.TempDir(), "second.tar.gz")
+	if err := TarGzDir(writeTestBundle(t, t.TempDir(), time.Now().Add(-time.Hour)), second); err != nil {
+		t.Fatalf("TarGzDir() error = %v", err)
+	}
+
+	firstContent, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondContent, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(firstContent, secondContent) {
+		t.Errorf("archives of the same content differ")
+	}
+	if _, err := os.Stat(first + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary archive left behind: %v", err)
+	}
+
+	type entry struct {
+		typeflag byte
+		mode     int64
+		link     string
+		content  string
+	}
+	want := map[string]entry{
+		"App.app/":                               {typeflag: tar.TypeDir, mode: 0755},
+		"App.app/App":                            {typeflag: tar.TypeReg, mode: 0755, content: "App"},
+		"App.app/Frameworks/":                    {typeflag: tar.TypeDir, mode: 0755},
+		"App.app/Frameworks/Core.framework/":     {typeflag: tar.TypeDir, mode: 0755},
+		"App.app/Frameworks/Core.framework/Core": {typeflag: tar.TypeReg, mode: 0755, content: "Frameworks/Core.framework/Core"},
+		"App.app/Frameworks/Current":             {typeflag: tar.TypeSymlink, mode: 0777, link: "Core.framework/Core"},
+		"App.app/Info.plist":                     {typeflag: tar.TypeReg, mode: 0644, content: "Info.plist"},
+	}
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(firstContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := tar.NewReader(gzipReader)
+	got := map[string]entry{}
+	var names []string
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !header.ModTime.Equal(archiveModTime) {
+			t.Errorf("%s: ModTime = %s, want %s", header.Name, header.ModTime, archiveModTime)
+		}
+		got[header.Name] = entry{typeflag: header.Typeflag, mode: header.Mode & 0777, link: header.Linkname, content: string(content)}
+		names = append(names, header.Name)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %+v, want %+v", got, want)
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] > names[i] {
+			t.Errorf("entries are not in lexical order: %q", names)
+			break
+		}
+	}
+}
+
+func TestTarGzDirMissingSource(t *testing.T) {
+	destination := filepath.Join(t.TempDir(), "App.app.tar.gz")
+	if err := TarGzDir(filepath.Join(t.TempDir(), "Missing.app"), destination); err == nil {
+		t.Fatalf("TarGzDir() error = nil, want an error")
+	}
+	for _, pth := range []string{destination, destination + ".tmp"} {
+		if _, err := os.Stat(pth); !os.IsNotExist(err) {
+			t.Errorf("%s left behind: %v", pth, err)
+		}
+	}
+}
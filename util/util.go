@@ -3,8 +3,6 @@ package util
 import (
 	"fmt"
 	"time"
-
-	"github.com/bitrise-io/go-utils/command"
 )
 
 type coloringFunc func(...interface{}) string
@@ -21,10 +19,3 @@ func LogWithTimestamp(coloringFunc coloringFunc, format string, v ...interface{}
 	messageWithTimeStamp := fmt.Sprintf("[%s] %s", currentTimestamp(), coloringFunc(message))
 	fmt.Println(messageWithTimeStamp)
 }
-
-// CopyDir ...
-func CopyDir(source string, destination string) *command.Model {
-	copyCmd := command.New("cp", "-R", source, destination)
-
-	return copyCmd
-}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestBundle creates App.app under dir with an executable, a plain file, a nested dir
+// and a symlink, all with the given modification time.
+func writeTestBundle(t *testing.T, dir string, modTime time.Time) string {
+	t.Helper()
+
+	bundle := filepath.Join(dir, "App.app")
+	for _, file := range []struct {
+		pth  string
+		mode os.FileMode
+	}{
+		{pth: "App", mode: 0755},
+		{pth: "Info.plist", mode: 0644},
+		{pth: filepath.Join("Frameworks", "Core.framework", "Core"), mode: 0755},
+	} {
+		pth := filepath.Join(bundle, file.pth)
+		if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(pth, []byte(file.pth), file.mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(pth, file.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("Core.framework/Core", filepath.Join(bundle, "Frameworks", "Current")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := filepath.Walk(bundle, func(pth string, info os.FileInfo, err error) error {
+		if err != nil || info.Mode()&os.ModeSymlink != 0 {
+			return err
+		}
+		return os.Chtimes(pth, modTime, modTime)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return bundle
+}
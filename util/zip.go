@@ -0,0 +1,111 @@
+package util
+
+import (
+	"archive/zip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// archiveModTime is used for every archive entry, so archiving the same content always gives the same archive.
+var archiveModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ZipDir zips the source dir (the dir itself is the root entry of the archive) into the destination zip.
+// Symlinks are stored as symlinks, file permissions are kept, and entries are written in a stable, lexical order
+// with a fixed modification time, so the output is reproducible.
+func ZipDir(sourceDir string, destinationZip string) error {
+	tmpZip := destinationZip + ".tmp"
+	if err := writeZip(sourceDir, tmpZip); err != nil {
+		_ = os.Remove(tmpZip)
+		return err
+	}
+
+	return os.Rename(tmpZip, destinationZip)
+}
+
+func writeZip(sourceDir string, destinationZip string) error {
+	file, err := os.Create(destinationZip)
+	if err != nil {
+		return err
+	}
+
+	writer := zip.NewWriter(file)
+	baseDir := filepath.Dir(sourceDir)
+
+	walkErr := filepath.WalkDir(sourceDir, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(baseDir, pth)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		return addZipEntry(writer, pth, filepath.ToSlash(rel), info)
+	})
+
+	closeErr := writer.Close()
+	fileCloseErr := file.Close()
+
+	if walkErr != nil {
+		return walkErr
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return fileCloseErr
+}
+
+func addZipEntry(writer *zip.Writer, pth, name string, info fs.FileInfo) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Modified = archiveModTime
+
+	switch {
+	case info.IsDir():
+		header.Name += "/"
+		header.Method = zip.Store
+	case info.Mode()&fs.ModeSymlink != 0:
+		header.Method = zip.Store
+	default:
+		header.Method = zip.Deflate
+	}
+
+	entry, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.IsDir():
+		return nil
+	case info.Mode()&fs.ModeSymlink != 0:
+		linkTarget, err := os.Readlink(pth)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(entry, linkTarget)
+		return err
+	}
+
+	file, err := os.Open(pth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	_, err = io.Copy(entry, file)
+	return err
+}
@@ -0,0 +1,98 @@
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestZipDir(t *testing.T) {
+	first := filepath.Join(t.TempDir(), "first.zip")
+	if err := ZipDir(writeTestBundle(t, t.TempDir(), time.Now()), first); err != nil {
+		t.Fatalf("ZipDir() error = %v", err)
+	}
+	second := filepath.Join(t.TempDir(), "second.zip")
+	if err := ZipDir(writeTestBundle(t, t.TempDir(), time.Now().Add(-time.Hour)), second); err != nil {
+		t.Fatalf("ZipDir() error = %v", err)
+	}
+
+	firstContent, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondContent, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(firstContent, secondContent) {
+		t.Errorf("archives of the same content differ")
+	}
+	if _, err := os.Stat(first + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary archive left behind: %v", err)
+	}
+
+	type entry struct {
+		mode    os.FileMode
+		content string
+	}
+	want := map[string]entry{
+		"App.app/":                               {mode: os.ModeDir | 0755},
+		"App.app/App":                            {mode: 0755, content: "App"},
+		"App.app/Frameworks/":                    {mode: os.ModeDir | 0755},
+		"App.app/Frameworks/Core.framework/":     {mode: os.ModeDir | 0755},
+		"App.app/Frameworks/Core.framework/Core": {mode: 0755, content: "Frameworks/Core.framework/Core"},
+		"App.app/Frameworks/Current":             {mode: os.ModeSymlink | 0777, content: "Core.framework/Core"},
+		"App.app/Info.plist":                     {mode: 0644, content: "Info.plist"},
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(firstContent), int64(len(firstContent)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]entry{}
+	var names []string
+	for _, file := range reader.File {
+		if !file.Modified.Equal(archiveModTime) {
+			t.Errorf("%s: Modified = %s, want %s", file.Name, file.Modified, archiveModTime)
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got[file.Name] = entry{mode: file.Mode() & (os.ModeDir | os.ModeSymlink | os.ModePerm), content: string(content)}
+		names = append(names, file.Name)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %+v, want %+v", got, want)
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] > names[i] {
+			t.Errorf("entries are not in lexical order: %q", names)
+			break
+		}
+	}
+}
+
+func TestZipDirMissingSource(t *testing.T) {
+	destination := filepath.Join(t.TempDir(), "App.app.zip")
+	if err := ZipDir(filepath.Join(t.TempDir(), "Missing.app"), destination); err == nil {
+		t.Fatalf("ZipDir() error = nil, want an error")
+	}
+	for _, pth := range []string{destination, destination + ".tmp"} {
+		if _, err := os.Stat(pth); !os.IsNotExist(err) {
+			t.Errorf("%s left behind: %v", pth, err)
+		}
+	}
+}
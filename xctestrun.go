@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+
+	"github.com/bitrise-steplib/steps-xcode-build-for-simulator/util"
+)
+
+type testBundle struct {
+	XctestrunPath string
+	TestDirPath   string
+}
+
+// copyTestBundleToOutputDir copies the .xctestrun file generated by `build-for-testing`
+// and the configuration's products dir (e.g. Debug-iphonesimulator) next to each other into the output dir,
+// so the xctestrun file's __TESTROOT__ relative paths keep working.
+func copyTestBundleToOutputDir(buildSettings serialized.Object, scheme, outputDir string) (testBundle, error) {
+	buildDir, err := buildSettings.String("BUILD_DIR")
+	if err != nil {
+		return testBundle{}, fmt.Errorf("failed to read build products dir: %s", err)
+	}
+	productsDir, err := buildSettings.String("CONFIGURATION_BUILD_DIR")
+	if err != nil {
+		return testBundle{}, fmt.Errorf("failed to read configuration build dir: %s", err)
+	}
+
+	xctestrunPth, err := findXctestrun(buildDir, scheme)
+	if err != nil {
+		return testBundle{}, err
+	}
+
+	bundle := testBundle{
+		XctestrunPath: filepath.Join(outputDir, filepath.Base(xctestrunPth)),
+		TestDirPath:   filepath.Join(outputDir, filepath.Base(productsDir)),
+	}
+
+	for _, pth := range []string{bundle.XctestrunPath, bundle.TestDirPath} {
+		if err := os.RemoveAll(pth); err != nil {
+			return testBundle{}, fmt.Errorf("failed to remove path (%s), error: %s", pth, err)
+		}
+	}
+
+	if err := util.CopyFile(xctestrunPth, bundle.XctestrunPath); err != nil {
+		return testBundle{}, fmt.Errorf("failed to copy %s: %s", xctestrunPth, err)
+	}
+	log.Donef("Copy: $BITRISE_DEPLOY_DIR/%s", filepath.Base(bundle.XctestrunPath))
+
+	if err := util.CopyDir(productsDir, bundle.TestDirPath); err != nil {
+		return testBundle{}, fmt.Errorf("failed to copy %s: %s", productsDir, err)
+	}
+	log.Donef("Copy: $BITRISE_DEPLOY_DIR/%s", filepath.Base(bundle.TestDirPath))
+
+	return bundle, nil
+}
+
+// findXctestrun returns the most recent .xctestrun file of the scheme in the build products dir.
+// xcodebuild names the file <scheme>_<test plan>_<platform><sdk version>-<arch>.xctestrun.
+func findXctestrun(buildDir, scheme string) (string, error) {
+	pths, err := filepath.Glob(filepath.Join(buildDir, "*.xctestrun"))
+	if err != nil {
+		return "", fmt.Errorf("failed to search for xctestrun files: %s", err)
+	}
+
+	type candidate struct {
+		pth     string
+		modTime int64
+	}
+	var candidates []candidate
+	for _, pth := range pths {
+		if !strings.HasPrefix(filepath.Base(pth), scheme+"_") {
+			log.Debugf("Skipping xctestrun file of another scheme: %s", pth)
+			continue
+		}
+
+		info, err := os.Stat(pth)
+		if err != nil {
+			return "", err
+		}
+		candidates = append(candidates, candidate{pth: pth, modTime: info.ModTime().UnixNano()})
+	}
+
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no xctestrun file found for scheme (%s) in %s", scheme, buildDir)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].modTime > candidates[j].modTime
+	})
+
+	return candidates[0].pth, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFindXctestrun(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		scheme  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "single file",
+			files:  []string{"App_iphonesimulator17.0-arm64.xctestrun"},
+			scheme: "App",
+			want:   "App_iphonesimulator17.0-arm64.xctestrun",
+		},
+		{
+			name:   "most recent file wins",
+			files:  []string{"App_Old_iphonesimulator16.0-arm64.xctestrun", "App_New_iphonesimulator17.0-arm64.xctestrun"},
+			scheme: "App",
+			want:   "App_New_iphonesimulator17.0-arm64.xctestrun",
+		},
+		{
+			name:   "files of other schemes are skipped",
+			files:  []string{"App_iphonesimulator17.0-arm64.xctestrun", "AppTests_iphonesimulator17.0-arm64.xctestrun"},
+			scheme: "App",
+			want:   "App_iphonesimulator17.0-arm64.xctestrun",
+		},
+		{
+			name:    "no file of the scheme",
+			files:   []string{"Other_iphonesimulator17.0-arm64.xctestrun"},
+			scheme:  "App",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buildDir := t.TempDir()
+			modTime := time.Now().Add(-time.Hour)
+			for _, name := range tt.files {
+				pth := filepath.Join(buildDir, name)
+				if err := os.WriteFile(pth, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(pth, modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+				modTime = modTime.Add(time.Minute)
+			}
+
+			got, err := findXctestrun(buildDir, tt.scheme)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findXctestrun() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if want := filepath.Join(buildDir, tt.want); got != want {
+				t.Errorf("findXctestrun() = %s, want %s", got, want)
+			}
+		})
+	}
+}